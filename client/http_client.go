@@ -3,6 +3,8 @@ package client
 
 import (
 	"bytes"
+	"context"
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -69,6 +71,14 @@ func (c *HTTPClient) BaseURL() string {
 	return c.config.BaseURL
 }
 
+// ArtifactTrustedKeys returns the configured artifact signing keys.
+func (c *HTTPClient) ArtifactTrustedKeys() map[string]ed25519.PublicKey {
+	if c == nil || c.config == nil {
+		return nil
+	}
+	return c.config.ArtifactTrustedKeys
+}
+
 // Config holds the configuration for the HTTP client.
 type Config struct {
 	BaseURL         string
@@ -77,6 +87,9 @@ type Config struct {
 	HTTPClient      interface{}
 	AccessKeyID     string
 	AccessKeySecret string
+	// ArtifactTrustedKeys maps signing key IDs to the ed25519 public keys
+	// whose artifact signatures are accepted on download.
+	ArtifactTrustedKeys map[string]ed25519.PublicKey
 }
 
 // Do sends an HTTP request and returns the response.
@@ -306,25 +319,58 @@ func (c *HTTPClient) doRawRequest(req *http.Request) (*RawResponse, error) {
 	utils.Trace("Response body: %s", string(respBody))
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		utils.Error("API error: status=%d, body=%s", resp.StatusCode, string(respBody))
-		var code utils.ErrorCode
-		switch {
-		case resp.StatusCode == 401:
-			code = utils.ErrCodeUnauthorized
-		case resp.StatusCode == 403:
-			code = utils.ErrCodeForbidden
-		case resp.StatusCode == 404:
-			code = utils.ErrCodeNotFound
-		case resp.StatusCode >= 500:
-			code = utils.ErrCodeAPIError
-		default:
-			code = utils.ErrCodeAPIError
-		}
 		errorMsg := fmt.Sprintf("API error: status=%d, body=%s", resp.StatusCode, string(respBody))
-		return &RawResponse{StatusCode: resp.StatusCode, Body: respBody, Header: resp.Header.Clone()}, utils.NewSDKError(code, errorMsg, nil)
+		return &RawResponse{StatusCode: resp.StatusCode, Body: respBody, Header: resp.Header.Clone()}, utils.NewSDKError(statusErrorCode(resp.StatusCode), errorMsg, nil)
 	}
 	return &RawResponse{StatusCode: resp.StatusCode, Body: respBody, Header: resp.Header.Clone()}, nil
 }
 
+// GetURLStream sends a GET to an absolute URL, such as a signed download
+// URL, and returns the response with its body unread. API credentials are
+// not sent, and the client timeout does not apply to reading the body; ctx
+// bounds the whole transfer instead. The caller must close the body.
+func (c *HTTPClient) GetURLStream(ctx context.Context, rawURL string, headers map[string]string) (*http.Response, error) {
+	req, err := c.newRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, utils.NewInternalError("failed to create request", err)
+	}
+	if c.config.UserAgent != "" {
+		req.Header.Set("User-Agent", c.config.UserAgent)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	streamClient := *c.client
+	streamClient.Timeout = 0
+	resp, err := streamClient.Do(req.WithContext(ctx))
+	if err != nil {
+		utils.Debug("Failed to send request: %v", err)
+		return nil, utils.NewNetworkError("failed to send request", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		errorMsg := fmt.Sprintf("download error: status=%d, body=%s", resp.StatusCode, string(respBody))
+		return nil, utils.NewSDKError(statusErrorCode(resp.StatusCode), errorMsg, nil)
+	}
+	return resp, nil
+}
+
+func statusErrorCode(statusCode int) utils.ErrorCode {
+	switch statusCode {
+	case 401:
+		return utils.ErrCodeUnauthorized
+	case 403:
+		return utils.ErrCodeForbidden
+	case 404:
+		return utils.ErrCodeNotFound
+	case 409:
+		return utils.ErrCodeConflict
+	default:
+		return utils.ErrCodeAPIError
+	}
+}
+
 func (c *HTTPClient) newRequest(method string, rawURL string, body io.Reader) (*http.Request, error) {
 	return http.NewRequest(method, rawURL, body)
 }
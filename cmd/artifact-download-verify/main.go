@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -72,48 +71,29 @@ func findMsgChild(client *intranet.Client, root *models.ArtifactInfo) (*models.A
 	if root == nil {
 		return nil, fmt.Errorf("root artifact is nil")
 	}
-	if root.ProjectName == nil || strings.TrimSpace(*root.ProjectName) == "" {
+	projectName := strings.TrimSpace(value(root.ProjectName))
+	if projectName == "" {
 		return nil, fmt.Errorf("root project name is empty")
 	}
 
-	wantName := strings.TrimSpace(*root.ProjectName) + "-msg"
-	wantPlatform := strings.TrimSpace(value(root.Platform))
-
-	for i := range root.Dependencies {
-		dep := root.Dependencies[i]
-		if dep.ID == nil {
-			continue
-		}
-		if strings.TrimSpace(value(dep.Name)) != wantName {
-			continue
-		}
-		if strings.TrimSpace(value(dep.Type)) != "app" {
-			continue
-		}
-		if strings.TrimSpace(value(dep.ModulePath)) != "" {
-			continue
-		}
-		if dep.IsVirtual != nil && *dep.IsVirtual {
-			continue
-		}
-		if wantPlatform != "" && strings.TrimSpace(value(dep.Platform)) != wantPlatform {
-			continue
-		}
-
-		detail, err := client.Artifact.GetArtifactByID(*dep.ID)
-		if err != nil {
-			return nil, fmt.Errorf("load msg dependency detail by id=%d: %w", *dep.ID, err)
-		}
-		ok, err := isStableMsgArtifact(detail, strings.TrimSpace(*root.ProjectName), wantPlatform)
-		if err != nil {
-			return nil, fmt.Errorf("validate msg dependency detail by id=%d: %w", *dep.ID, err)
-		}
-		if !ok {
-			continue
-		}
-		return detail, nil
+	name := projectName + "-msg"
+	artifactType := "app"
+	modulePath := ""
+	extra := map[string]any{
+		"artifact_type": "msg",
+		"project_name":  projectName,
+	}
+	query := &models.ArtifactDependencyQuery{
+		Name:       &name,
+		Type:       &artifactType,
+		ModulePath: &modulePath,
+		Extra:      extra,
+	}
+	if platform := strings.TrimSpace(value(root.Platform)); platform != "" {
+		query.Platform = &platform
+		extra["platform"] = platform
 	}
-	return nil, fmt.Errorf("stable msg child not found")
+	return client.Artifact.FindArtifactDependency(root, query)
 }
 
 func value(v *string) string {
@@ -123,33 +103,6 @@ func value(v *string) string {
 	return *v
 }
 
-func isStableMsgArtifact(artifact *models.ArtifactInfo, projectName, platform string) (bool, error) {
-	if artifact == nil {
-		return false, nil
-	}
-
-	extra := strings.TrimSpace(value(artifact.Extra))
-	if extra == "" {
-		return false, nil
-	}
-
-	var payload map[string]string
-	if err := json.Unmarshal([]byte(extra), &payload); err != nil {
-		return false, err
-	}
-
-	if strings.TrimSpace(payload["artifact_type"]) != "msg" {
-		return false, nil
-	}
-	if projectName != "" && strings.TrimSpace(payload["project_name"]) != projectName {
-		return false, nil
-	}
-	if platform != "" && strings.TrimSpace(payload["platform"]) != platform {
-		return false, nil
-	}
-	return true, nil
-}
-
 func init() {
 	if err := os.MkdirAll(filepath.Join("downloads", rootCommitHash), 0o755); err != nil {
 		log.Fatalf("prepare download directory failed: %v", err)
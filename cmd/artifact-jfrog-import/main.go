@@ -0,0 +1,58 @@
+// Command artifact-jfrog-import registers JFrog files that have no artifact.
+//
+// The command runs in dry-run mode unless -apply is set and prints the
+// import report as JSON. Credentials are read from INTRANET_BASE_URL,
+// INTRANET_ACCESS_KEY_ID and INTRANET_ACCESS_KEY_SECRET.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	intranet "github.com/hujia-team/intranet-sdk"
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func main() {
+	projectName := flag.String("project", "", "project whose JFrog token is used and whose artifacts are reconciled")
+	pattern := flag.String("pattern", "", "JFrog search pattern, e.g. generic-local/vision/*")
+	convention := flag.String("convention", "", "path convention, e.g. {repo}/{name}/{platform}/{version}/{file}")
+	artifactType := flag.String("type", "", "artifact type when the convention has no {type}")
+	apply := flag.Bool("apply", false, "register artifacts instead of a dry run")
+	flag.Parse()
+	if *projectName == "" || *pattern == "" || *convention == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	options := []intranet.Option{
+		intranet.WithAccessKeyID(os.Getenv("INTRANET_ACCESS_KEY_ID")),
+		intranet.WithAccessKeySecret(os.Getenv("INTRANET_ACCESS_KEY_SECRET")),
+	}
+	if baseURL := os.Getenv("INTRANET_BASE_URL"); baseURL != "" {
+		options = append(options, intranet.WithBaseURL(baseURL))
+	}
+	client, err := intranet.NewClient(options...)
+	if err != nil {
+		log.Fatalf("init sdk failed: %v", err)
+	}
+
+	report, err := client.Artifact.ImportJfrogArtifacts(&models.ArtifactImportReq{
+		ProjectName:    *projectName,
+		Pattern:        *pattern,
+		PathConvention: *convention,
+		Type:           *artifactType,
+		DryRun:         !*apply,
+	})
+	if err != nil {
+		log.Fatalf("import jfrog artifacts failed: %v", err)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(report)
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
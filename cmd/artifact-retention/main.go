@@ -0,0 +1,93 @@
+// Command artifact-retention applies retention rules to artifacts.
+//
+// The command runs in dry-run mode unless -apply is set and prints the
+// retention report as JSON. Credentials are read from INTRANET_BASE_URL,
+// INTRANET_ACCESS_KEY_ID and INTRANET_ACCESS_KEY_SECRET.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	intranet "github.com/hujia-team/intranet-sdk"
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+type keepTagFlags map[string]any
+
+func (f keepTagFlags) String() string {
+	return ""
+}
+
+func (f keepTagFlags) Set(value string) error {
+	key, raw, ok := strings.Cut(value, "=")
+	if !ok {
+		f[value] = true
+		return nil
+	}
+	var decoded any
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		decoded = raw
+	}
+	f[key] = decoded
+	return nil
+}
+
+func main() {
+	keepTags := keepTagFlags{}
+	name := flag.String("name", "", "filter artifacts by name")
+	projectName := flag.String("project", "", "filter artifacts by project name")
+	artifactType := flag.String("type", "", "filter artifacts by type")
+	platform := flag.String("platform", "", "filter artifacts by platform")
+	keepLast := flag.Int("keep-last", 0, "keep the newest N artifacts per name/platform")
+	maxAgeDays := flag.Int("max-age-days", 0, "delete artifacts older than this many days")
+	batchSize := flag.Int("batch", 0, "delete batch size")
+	apply := flag.Bool("apply", false, "delete artifacts instead of a dry run")
+	flag.Var(keepTags, "keep-tag", "keep artifacts whose tag matches key=value, repeatable")
+	flag.Parse()
+
+	options := []intranet.Option{
+		intranet.WithAccessKeyID(os.Getenv("INTRANET_ACCESS_KEY_ID")),
+		intranet.WithAccessKeySecret(os.Getenv("INTRANET_ACCESS_KEY_SECRET")),
+	}
+	if baseURL := os.Getenv("INTRANET_BASE_URL"); baseURL != "" {
+		options = append(options, intranet.WithBaseURL(baseURL))
+	}
+	client, err := intranet.NewClient(options...)
+	if err != nil {
+		log.Fatalf("init sdk failed: %v", err)
+	}
+
+	req := &models.ArtifactRetentionReq{
+		Filter:     &models.ArtifactListReq{},
+		KeepLast:   *keepLast,
+		KeepTags:   keepTags,
+		MaxAgeDays: *maxAgeDays,
+		BatchSize:  *batchSize,
+		DryRun:     !*apply,
+	}
+	setIfNotEmpty(&req.Filter.Name, *name)
+	setIfNotEmpty(&req.Filter.ProjectName, *projectName)
+	setIfNotEmpty(&req.Filter.Type, *artifactType)
+	setIfNotEmpty(&req.Filter.Platform, *platform)
+
+	report, err := client.Artifact.ApplyArtifactRetention(req)
+	if err != nil {
+		log.Fatalf("apply artifact retention failed: %v", err)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(report)
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func setIfNotEmpty(target **string, value string) {
+	if value != "" {
+		*target = &value
+	}
+}
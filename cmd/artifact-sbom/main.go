@@ -0,0 +1,66 @@
+// Command artifact-sbom exports a CycloneDX 1.5 SBOM for an artifact and its lineage.
+//
+// Credentials are read from INTRANET_BASE_URL, INTRANET_ACCESS_KEY_ID and
+// INTRANET_ACCESS_KEY_SECRET.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	intranet "github.com/hujia-team/intranet-sdk"
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func main() {
+	artifactID := flag.Uint64("id", 0, "root artifact ID")
+	format := flag.String("format", "json", "SBOM format: json or xml")
+	outPath := flag.String("out", "", "output file, defaults to stdout")
+	flag.Parse()
+
+	if err := run(*artifactID, models.ArtifactSBOMFormat(*format), *outPath); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run exports the SBOM and returns instead of exiting so that the output
+// file is closed on every path.
+func run(artifactID uint64, format models.ArtifactSBOMFormat, outPath string) (err error) {
+	if artifactID == 0 {
+		return errors.New("-id is required")
+	}
+
+	options := []intranet.Option{
+		intranet.WithAccessKeyID(os.Getenv("INTRANET_ACCESS_KEY_ID")),
+		intranet.WithAccessKeySecret(os.Getenv("INTRANET_ACCESS_KEY_SECRET")),
+	}
+	if baseURL := os.Getenv("INTRANET_BASE_URL"); baseURL != "" {
+		options = append(options, intranet.WithBaseURL(baseURL))
+	}
+	client, err := intranet.NewClient(options...)
+	if err != nil {
+		return fmt.Errorf("init sdk failed: %w", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if outPath != "" {
+		file, err := os.Create(outPath)
+		if err != nil {
+			return fmt.Errorf("create output failed: %w", err)
+		}
+		defer func() {
+			if closeErr := file.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("close output failed: %w", closeErr)
+			}
+		}()
+		out = file
+	}
+	if err := client.Artifact.ExportArtifactSBOM(artifactID, format, out); err != nil {
+		return fmt.Errorf("export sbom failed: %w", err)
+	}
+	return nil
+}
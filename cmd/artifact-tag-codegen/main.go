@@ -0,0 +1,58 @@
+// Command artifact-tag-codegen generates typed Go structs from an artifact tag schema.
+//
+// Usage:
+//
+//	artifact-tag-codegen -version 0.2.0 -package tags -type ArtifactTags -out tags/artifact_tags.go
+//
+// Credentials are read from INTRANET_BASE_URL, INTRANET_ACCESS_KEY_ID and
+// INTRANET_ACCESS_KEY_SECRET.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	intranet "github.com/hujia-team/intranet-sdk"
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func main() {
+	version := flag.String("version", "", "tag schema version, empty for the latest schema")
+	packageName := flag.String("package", "tags", "package name of the generated file")
+	typeName := flag.String("type", "ArtifactTags", "name of the root tag struct")
+	output := flag.String("out", "", "output file, stdout when empty")
+	flag.Parse()
+
+	options := []intranet.Option{
+		intranet.WithAccessKeyID(os.Getenv("INTRANET_ACCESS_KEY_ID")),
+		intranet.WithAccessKeySecret(os.Getenv("INTRANET_ACCESS_KEY_SECRET")),
+	}
+	if baseURL := os.Getenv("INTRANET_BASE_URL"); baseURL != "" {
+		options = append(options, intranet.WithBaseURL(baseURL))
+	}
+	client, err := intranet.NewClient(options...)
+	if err != nil {
+		log.Fatalf("init sdk failed: %v", err)
+	}
+
+	source, err := client.Artifact.GenerateArtifactTagTypes(*version, &models.ArtifactTagCodegenOptions{
+		PackageName: *packageName,
+		TypeName:    *typeName,
+	})
+	if err != nil {
+		log.Fatalf("generate tag types failed: %v", err)
+	}
+
+	if *output == "" {
+		_, _ = os.Stdout.Write(source)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(*output), 0o755); err != nil {
+		log.Fatalf("prepare output directory failed: %v", err)
+	}
+	if err := os.WriteFile(*output, source, 0o644); err != nil {
+		log.Fatalf("write generated file failed: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+// Command artifact-tag-migrate rewrites stored artifact tags to a target schema version.
+//
+// Migrations are loaded from a JSON file holding a list of patch-based steps:
+//
+//	[{"from":"0.1.x","to":"0.2.0","patchType":"json-patch","patch":[{"op":"move","from":"/result","path":"/decision"}]}]
+//
+// The command runs in dry-run mode unless -apply is set and prints the
+// per-artifact report as JSON. Credentials are read from INTRANET_BASE_URL,
+// INTRANET_ACCESS_KEY_ID and INTRANET_ACCESS_KEY_SECRET.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	intranet "github.com/hujia-team/intranet-sdk"
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func main() {
+	targetVersion := flag.String("to", "", "target tag schema version, empty for the latest schema")
+	migrationsFile := flag.String("migrations", "", "JSON file with migration steps")
+	name := flag.String("name", "", "filter artifacts by name")
+	projectName := flag.String("project", "", "filter artifacts by project name")
+	artifactType := flag.String("type", "", "filter artifacts by type")
+	platform := flag.String("platform", "", "filter artifacts by platform")
+	fromVersion := flag.String("from-version", "", "filter artifacts by stored tag schema version")
+	ids := flag.String("ids", "", "comma separated artifact IDs, overrides the filters")
+	apply := flag.Bool("apply", false, "write migrated tags instead of a dry run")
+	flag.Parse()
+
+	options := []intranet.Option{
+		intranet.WithAccessKeyID(os.Getenv("INTRANET_ACCESS_KEY_ID")),
+		intranet.WithAccessKeySecret(os.Getenv("INTRANET_ACCESS_KEY_SECRET")),
+	}
+	if baseURL := os.Getenv("INTRANET_BASE_URL"); baseURL != "" {
+		options = append(options, intranet.WithBaseURL(baseURL))
+	}
+	client, err := intranet.NewClient(options...)
+	if err != nil {
+		log.Fatalf("init sdk failed: %v", err)
+	}
+
+	if *migrationsFile != "" {
+		raw, err := os.ReadFile(*migrationsFile)
+		if err != nil {
+			log.Fatalf("read migrations failed: %v", err)
+		}
+		var migrations []models.ArtifactTagMigration
+		if err := json.Unmarshal(raw, &migrations); err != nil {
+			log.Fatalf("decode migrations failed: %v", err)
+		}
+		for _, migration := range migrations {
+			if err := client.Artifact.RegisterArtifactTagMigration(migration); err != nil {
+				log.Fatalf("register migration failed: %v", err)
+			}
+		}
+	}
+
+	req := &models.ArtifactTagMigrationReq{
+		TargetVersion: *targetVersion,
+		DryRun:        !*apply,
+		Filter:        &models.ArtifactListReq{},
+	}
+	setIfNotEmpty(&req.Filter.Name, *name)
+	setIfNotEmpty(&req.Filter.ProjectName, *projectName)
+	setIfNotEmpty(&req.Filter.Type, *artifactType)
+	setIfNotEmpty(&req.Filter.Platform, *platform)
+	setIfNotEmpty(&req.Filter.TagSchemaVersion, *fromVersion)
+	for _, rawID := range strings.Split(*ids, ",") {
+		if strings.TrimSpace(rawID) == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSpace(rawID), 10, 64)
+		if err != nil {
+			log.Fatalf("invalid artifact id %q: %v", rawID, err)
+		}
+		req.ArtifactIDs = append(req.ArtifactIDs, id)
+	}
+
+	report, err := client.Artifact.MigrateArtifactTags(req)
+	if report != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+	}
+	if err != nil {
+		log.Fatalf("migrate artifact tags failed: %v", err)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func setIfNotEmpty(target **string, value string) {
+	if value != "" {
+		*target = &value
+	}
+}
@@ -2,6 +2,7 @@
 package intranet
 
 import (
+	"crypto/ed25519"
 	"net/http"
 
 	"github.com/hujia-team/intranet-sdk/client"
@@ -114,3 +115,11 @@ func WithHTTPClient(httpClient *http.Client) Option {
 		c.HTTPClient = httpClient
 	}
 }
+
+// WithArtifactTrustedKeys makes artifact downloads verify detached signatures
+// against the given key IDs and refuse unsigned or mis-signed artifacts.
+func WithArtifactTrustedKeys(keys map[string]ed25519.PublicKey) Option {
+	return func(c *client.Config) {
+		c.ArtifactTrustedKeys = keys
+	}
+}
@@ -1,7 +1,10 @@
 // Package models defines the data structures used in the MiniEye Intranet API.
 package models
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // CommitInfo describes a git commit associated with an artifact.
 type CommitInfo struct {
@@ -103,6 +106,35 @@ type ArtifactLookupOptions struct {
 	SemanticVersion string
 	IncludeVirtual  *bool
 	ProjectName     string
+	// Selection picks one artifact when a name lookup matches several.
+	// Without it, ambiguous name lookups fail.
+	Selection *ArtifactSelectionStrategy
+}
+
+// ArtifactSelectionCriterion ranks name lookup candidates.
+type ArtifactSelectionCriterion string
+
+// Artifact selection criteria.
+const (
+	ArtifactSelectNewestBuild       ArtifactSelectionCriterion = "newest_build"
+	ArtifactSelectHighestVersion    ArtifactSelectionCriterion = "highest_version"
+	ArtifactSelectPreferredPlatform ArtifactSelectionCriterion = "preferred_platform"
+	ArtifactSelectNonVirtualFirst   ArtifactSelectionCriterion = "non_virtual_first"
+)
+
+// ArtifactSelectionStrategy orders name lookup candidates by each criterion
+// in turn, falling back to the highest ID so the choice is deterministic.
+// An empty Order means non_virtual_first, then newest_build.
+type ArtifactSelectionStrategy struct {
+	Order              []ArtifactSelectionCriterion `json:"order,omitempty"`
+	PreferredPlatforms []string                     `json:"preferredPlatforms,omitempty"`
+}
+
+// ArtifactNameSelection holds the selected artifact and every candidate,
+// ordered from most to least preferred.
+type ArtifactNameSelection struct {
+	Selected   *ArtifactInfo  `json:"selected,omitempty"`
+	Candidates []ArtifactInfo `json:"candidates,omitempty"`
 }
 
 // ArtifactChildHashInfo describes one dependency node extracted from artifact lineage.
@@ -138,6 +170,9 @@ type JfrogTokenInfo struct {
 	TokenType   string `json:"token_type"`
 	Scope       string `json:"scope"`
 	URL         string `json:"url"`
+	// ExpiresAt is the absolute expiry in unix seconds, derived by the SDK
+	// from ExpiresIn when the token is fetched. Zero means unknown.
+	ExpiresAt int64 `json:"expires_at,omitempty"`
 }
 
 // ArtifactDownloadURLInfo contains a signed download URL.
@@ -146,6 +181,8 @@ type ArtifactDownloadURLInfo struct {
 	ExpireTime  string `json:"expireTime"`
 	FileName    string `json:"fileName"`
 	FilePath    string `json:"filePath"`
+	// ExpiresAt is ExpireTime parsed into unix seconds. Zero means unknown.
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
 }
 
 // ArtifactExistenceInfo describes whether an artifact exists for a lookup target.
@@ -192,6 +229,32 @@ type ArtifactVersionMetadataInfo struct {
 	MetadataFileName *string        `json:"metadataFileName,omitempty"`
 	RawContent       *string        `json:"rawContent,omitempty"`
 	Parsed           map[string]any `json:"-"`
+	// Format is the format RawContent was parsed as.
+	Format ArtifactVersionMetadataFormat `json:"-"`
+}
+
+// ArtifactVersionMetadataFormat names a version metadata file format.
+type ArtifactVersionMetadataFormat string
+
+// Supported version metadata formats. Properties covers key=value manifests
+// such as .properties and .env files.
+const (
+	ArtifactVersionMetadataJSON       ArtifactVersionMetadataFormat = "json"
+	ArtifactVersionMetadataXML        ArtifactVersionMetadataFormat = "xml"
+	ArtifactVersionMetadataYAML       ArtifactVersionMetadataFormat = "yaml"
+	ArtifactVersionMetadataTOML       ArtifactVersionMetadataFormat = "toml"
+	ArtifactVersionMetadataINI        ArtifactVersionMetadataFormat = "ini"
+	ArtifactVersionMetadataProperties ArtifactVersionMetadataFormat = "properties"
+)
+
+// ArtifactVersionMetadataOptions controls how version metadata is loaded.
+// Format overrides detection from the file name. With Download, the file is
+// downloaded through GetArtifactDownloadURL with DownloadType (default
+// "metadata") instead of using the RawContent returned by the API.
+type ArtifactVersionMetadataOptions struct {
+	Format       ArtifactVersionMetadataFormat `json:"format,omitempty"`
+	Download     bool                          `json:"download,omitempty"`
+	DownloadType string                        `json:"downloadType,omitempty"`
 }
 
 // ArtifactDownloadPlan describes a resolved download plan for one artifact.
@@ -202,6 +265,9 @@ type ArtifactDownloadPlan struct {
 	TargetPath      string                   `json:"targetPath"`
 	Checksum        string                   `json:"checksum,omitempty"`
 	SkippedExisting bool                     `json:"skippedExisting,omitempty"`
+	// Stale is set when the token or download URL expired, or is about to,
+	// at the last check. ExecuteDownloadPlan refreshes stale plans.
+	Stale bool `json:"stale,omitempty"`
 }
 
 // RepoDiff groups artifact commit differences by repository.
@@ -222,6 +288,57 @@ type ArtifactCommitDiffInfo struct {
 	RepoDiffs        []RepoDiff `json:"repoDiffs"`
 }
 
+// ArtifactBuildRef identifies one build by ID, commit hash or semantic
+// version. Exactly one field must be set; versions are looked up by the
+// name of the request they belong to.
+type ArtifactBuildRef struct {
+	ID              uint64 `json:"id,omitempty"`
+	CommitHash      string `json:"commitHash,omitempty"`
+	SemanticVersion string `json:"semanticVersion,omitempty"`
+}
+
+// ArtifactCommitDiffSeriesReq diffs consecutive builds of a release series,
+// ordered from oldest to newest.
+type ArtifactCommitDiffSeriesReq struct {
+	Name   string                 `json:"name,omitempty"`
+	Lookup *ArtifactLookupOptions `json:"lookup,omitempty"`
+	Builds []ArtifactBuildRef     `json:"builds"`
+}
+
+// ArtifactCommitDiffSeries holds the diff of every step of a series and the
+// commits of all steps, de-duplicated by repository and commit hash in
+// first-seen order.
+type ArtifactCommitDiffSeries struct {
+	Steps   []ArtifactCommitDiffInfo `json:"steps"`
+	Commits []CommitInfo             `json:"commits"`
+}
+
+// ArtifactCommitSearchReq finds artifacts that contain a commit. CommitHash
+// may be a full or short hash. Lookup fields are applied as server-side list
+// filters. With DirectOnly, only the artifact's own commit hash and commits
+// are checked; otherwise dependency commits at any depth are included.
+type ArtifactCommitSearchReq struct {
+	CommitHash string                 `json:"commitHash"`
+	Lookup     *ArtifactLookupOptions `json:"lookup,omitempty"`
+	DirectOnly bool                   `json:"directOnly,omitempty"`
+}
+
+// ArtifactCommitMatch is one artifact containing the searched commit.
+// Dependency is set when the commit came in through a dependency.
+type ArtifactCommitMatch struct {
+	Artifact   ArtifactInfo            `json:"artifact"`
+	Commit     *CommitInfo             `json:"commit,omitempty"`
+	Dependency *ArtifactDependencyInfo `json:"dependency,omitempty"`
+}
+
+// ArtifactCommitSearchResult lists matches from oldest to newest build and
+// the first build containing the commit per platform.
+type ArtifactCommitSearchResult struct {
+	CommitHash      string                         `json:"commitHash"`
+	Matches         []ArtifactCommitMatch          `json:"matches"`
+	FirstByPlatform map[string]ArtifactCommitMatch `json:"firstByPlatform"`
+}
+
 // IDsReq is a numeric ID list request.
 type IDsReq struct {
 	IDs []uint64 `json:"ids"`
@@ -261,6 +378,653 @@ type ArtifactTagUpdateReq struct {
 	TagSchemaVersion string `json:"tagSchemaVersion,omitempty"`
 }
 
+// ArtifactConditionalUpdateReq is an artifact update carrying the updatedAt
+// it was derived from. The field is not part of the documented update
+// contract, so the SDK also compares updatedAt itself before writing; servers
+// that enforce ExpectedUpdatedAt answer 409 Conflict on a mismatch.
+type ArtifactConditionalUpdateReq struct {
+	ArtifactInfo
+	ExpectedUpdatedAt *int64 `json:"expectedUpdatedAt,omitempty"`
+}
+
+// ArtifactTagPatchType identifies the patch document format used by PatchArtifactTags.
+type ArtifactTagPatchType string
+
+const (
+	// ArtifactTagMergePatch is an RFC 7396 JSON Merge Patch document.
+	ArtifactTagMergePatch ArtifactTagPatchType = "merge-patch"
+	// ArtifactTagJSONPatch is an RFC 6902 JSON Patch document.
+	ArtifactTagJSONPatch ArtifactTagPatchType = "json-patch"
+)
+
+// ArtifactTagPatchReq describes a partial tag update applied to the current tags.
+type ArtifactTagPatchReq struct {
+	Type             ArtifactTagPatchType `json:"type"`
+	Patch            json.RawMessage      `json:"patch"`
+	TagSchemaVersion string               `json:"tagSchemaVersion,omitempty"`
+	MaxRetries       int                  `json:"maxRetries,omitempty"`
+}
+
+// ArtifactTagPatchResult describes the tags written by PatchArtifactTags.
+type ArtifactTagPatchResult struct {
+	ArtifactID       uint64         `json:"artifactId"`
+	Tags             map[string]any `json:"tags"`
+	TagSchemaVersion string         `json:"tagSchemaVersion,omitempty"`
+	Attempts         int            `json:"attempts"`
+	Response         *BaseMsgResp   `json:"response,omitempty"`
+}
+
+// ArtifactTagCodegenOptions controls Go code generation from an artifact tag schema.
+type ArtifactTagCodegenOptions struct {
+	PackageName string `json:"packageName"`
+	TypeName    string `json:"typeName"`
+}
+
+// ArtifactTagMigrateFunc transforms tags from one schema version to the next.
+type ArtifactTagMigrateFunc func(tags map[string]any) (map[string]any, error)
+
+// ArtifactTagMigration describes one transform between tag schema versions.
+// From may use x or * wildcards, e.g. 0.1.x. Either Migrate or a Patch document
+// must be set; patch-based migrations can be loaded from JSON files.
+type ArtifactTagMigration struct {
+	From      string                 `json:"from"`
+	To        string                 `json:"to"`
+	PatchType ArtifactTagPatchType   `json:"patchType,omitempty"`
+	Patch     json.RawMessage        `json:"patch,omitempty"`
+	Migrate   ArtifactTagMigrateFunc `json:"-"`
+}
+
+// ArtifactTagMigrationReq selects the artifacts whose stored tags are migrated.
+type ArtifactTagMigrationReq struct {
+	Filter        *ArtifactListReq `json:"filter,omitempty"`
+	ArtifactIDs   []uint64         `json:"artifactIds,omitempty"`
+	TargetVersion string           `json:"targetVersion"`
+	DryRun        bool             `json:"dryRun"`
+}
+
+// Artifact tag migration result statuses.
+const (
+	ArtifactTagMigrationMigrated     = "migrated"
+	ArtifactTagMigrationWouldMigrate = "would_migrate"
+	ArtifactTagMigrationSkipped      = "skipped"
+	ArtifactTagMigrationFailed       = "failed"
+)
+
+// ArtifactTagMigrationResult describes the migration outcome of one artifact.
+type ArtifactTagMigrationResult struct {
+	ArtifactID  uint64         `json:"artifactId"`
+	Name        *string        `json:"name,omitempty"`
+	FromVersion string         `json:"fromVersion,omitempty"`
+	ToVersion   string         `json:"toVersion,omitempty"`
+	Status      string         `json:"status"`
+	Steps       []string       `json:"steps,omitempty"`
+	Tags        map[string]any `json:"tags,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// ArtifactTagMigrationReport summarizes a bulk tag migration.
+type ArtifactTagMigrationReport struct {
+	TargetVersion string                       `json:"targetVersion"`
+	DryRun        bool                         `json:"dryRun"`
+	Total         int                          `json:"total"`
+	Migrated      int                          `json:"migrated"`
+	Skipped       int                          `json:"skipped"`
+	Failed        int                          `json:"failed"`
+	Results       []ArtifactTagMigrationResult `json:"results"`
+}
+
+// ArtifactVersionResolveReq resolves the best artifact for a semantic-version constraint.
+// Constraint accepts forms such as ^1.4, ~1.4.2, >=1.2 <2, 1.4.x, a || b and latest.
+// Lookup.SemanticVersion is ignored in favour of Constraint.
+type ArtifactVersionResolveReq struct {
+	Name              string                 `json:"name"`
+	Lookup            *ArtifactLookupOptions `json:"lookup,omitempty"`
+	Constraint        string                 `json:"constraint"`
+	IncludePrerelease bool                   `json:"includePrerelease,omitempty"`
+}
+
+// ArtifactVersionResolution holds the best match and the other matching candidates,
+// ordered from highest to lowest version.
+type ArtifactVersionResolution struct {
+	Constraint string         `json:"constraint"`
+	Best       *ArtifactInfo  `json:"best,omitempty"`
+	Candidates []ArtifactInfo `json:"candidates,omitempty"`
+}
+
+// ArtifactLockfileVersion is the current lockfile format version.
+const ArtifactLockfileVersion = 1
+
+// ArtifactLockfile pins the exact artifacts of a reproducible artifact set.
+type ArtifactLockfile struct {
+	Version     int                 `json:"version"`
+	GeneratedAt int64               `json:"generatedAt,omitempty"`
+	Entries     []ArtifactLockEntry `json:"entries"`
+}
+
+// ArtifactLockEntry records one resolved artifact and where it is placed locally.
+type ArtifactLockEntry struct {
+	ID              uint64 `json:"id"`
+	Name            string `json:"name"`
+	Type            string `json:"type,omitempty"`
+	CommitHash      string `json:"commitHash"`
+	Platform        string `json:"platform,omitempty"`
+	ProjectName     string `json:"projectName,omitempty"`
+	SemanticVersion string `json:"semanticVersion,omitempty"`
+	FullPath        string `json:"fullPath,omitempty"`
+	FileHash        string `json:"fileHash"`
+	TargetPath      string `json:"targetPath"`
+}
+
+// ArtifactLockRequest describes one artifact to resolve into a lockfile entry.
+// Exactly one of ArtifactID, CommitHash or Name is used, in that order;
+// Constraint applies to name lookups. Destination follows the download APIs:
+// a directory or a file path.
+type ArtifactLockRequest struct {
+	ArtifactID  uint64                 `json:"artifactId,omitempty"`
+	CommitHash  string                 `json:"commitHash,omitempty"`
+	Name        string                 `json:"name,omitempty"`
+	Constraint  string                 `json:"constraint,omitempty"`
+	Lookup      *ArtifactLookupOptions `json:"lookup,omitempty"`
+	Destination string                 `json:"destination"`
+}
+
+// Artifact lockfile entry statuses.
+const (
+	ArtifactLockStatusOK         = "ok"
+	ArtifactLockStatusMissing    = "missing"
+	ArtifactLockStatusMismatch   = "mismatch"
+	ArtifactLockStatusDownloaded = "downloaded"
+)
+
+// ArtifactLockEntryStatus describes the local state of one lockfile entry.
+type ArtifactLockEntryStatus struct {
+	Entry      ArtifactLockEntry `json:"entry"`
+	Path       string            `json:"path"`
+	Status     string            `json:"status"`
+	ActualHash string            `json:"actualHash,omitempty"`
+}
+
+// ArtifactLockVerifyResult is the outcome of verifying or syncing a lockfile.
+type ArtifactLockVerifyResult struct {
+	OK      bool                      `json:"ok"`
+	Entries []ArtifactLockEntryStatus `json:"entries"`
+}
+
+// ArtifactSBOMFormat selects the SBOM serialization format.
+type ArtifactSBOMFormat string
+
+// Supported SBOM formats.
+const (
+	ArtifactSBOMJSON ArtifactSBOMFormat = "json"
+	ArtifactSBOMXML  ArtifactSBOMFormat = "xml"
+)
+
+// Artifact diff change kinds.
+const (
+	ArtifactChangeAdded    = "added"
+	ArtifactChangeRemoved  = "removed"
+	ArtifactChangeModified = "modified"
+	ArtifactChangeRepinned = "repinned"
+)
+
+// ArtifactValueChange is one changed metadata field, or one changed JSON
+// pointer path inside tags or Extra.
+type ArtifactValueChange struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// ArtifactDependencyChange is one dependency that was added, removed or
+// re-pinned to another build. Dependencies are matched by name, type,
+// platform and module path.
+type ArtifactDependencyChange struct {
+	Key  string                  `json:"key"`
+	Kind string                  `json:"kind"`
+	Old  *ArtifactDependencyInfo `json:"old,omitempty"`
+	New  *ArtifactDependencyInfo `json:"new,omitempty"`
+}
+
+// ArtifactDiff is the structural difference from artifact A to artifact B.
+type ArtifactDiff struct {
+	ArtifactIDA  uint64                     `json:"artifactIdA"`
+	ArtifactIDB  uint64                     `json:"artifactIdB"`
+	Metadata     []ArtifactValueChange      `json:"metadata,omitempty"`
+	Tags         []ArtifactValueChange      `json:"tags,omitempty"`
+	Extra        []ArtifactValueChange      `json:"extra,omitempty"`
+	Dependencies []ArtifactDependencyChange `json:"dependencies,omitempty"`
+}
+
+// ArtifactDiffFormat selects how an artifact diff is rendered.
+type ArtifactDiffFormat string
+
+// Supported artifact diff formats.
+const (
+	ArtifactDiffText ArtifactDiffFormat = "text"
+	ArtifactDiffJSON ArtifactDiffFormat = "json"
+)
+
+// ArtifactChangelogFormat selects how a changelog is rendered.
+type ArtifactChangelogFormat string
+
+// Supported changelog formats.
+const (
+	ArtifactChangelogMarkdown ArtifactChangelogFormat = "markdown"
+	ArtifactChangelogHTML     ArtifactChangelogFormat = "html"
+	ArtifactChangelogJSON     ArtifactChangelogFormat = "json"
+)
+
+// ArtifactChangelogOptions configures changelog generation. Commit links are
+// built from RepositoryPath: absolute URLs are used as they are, relative
+// paths are joined to RepositoryBaseURL, and the result replaces
+// {repository} in CommitURLTemplate together with {hash} and {shortHash}.
+// The template defaults to GitLab's "{repository}/-/commit/{hash}"; use
+// "{repository}/commit/{hash}" for GitHub or Gitea. CommitURL overrides all
+// of this.
+// Template replaces the built-in Markdown or HTML template and is executed
+// with the ArtifactChangelog as data.
+type ArtifactChangelogOptions struct {
+	Format            ArtifactChangelogFormat                        `json:"format,omitempty"`
+	Title             string                                         `json:"title,omitempty"`
+	RepositoryBaseURL string                                         `json:"repositoryBaseUrl,omitempty"`
+	CommitURLTemplate string                                         `json:"commitUrlTemplate,omitempty"`
+	Template          string                                         `json:"template,omitempty"`
+	CommitURL         func(repositoryPath, commitHash string) string `json:"-"`
+}
+
+// ArtifactChangelogEntry is one commit parsed as a conventional commit.
+// Commits without a recognised prefix have type "other".
+type ArtifactChangelogEntry struct {
+	Type        string `json:"type"`
+	Scope       string `json:"scope,omitempty"`
+	Subject     string `json:"subject"`
+	Breaking    bool   `json:"breaking,omitempty"`
+	CommitHash  string `json:"commitHash"`
+	ShortHash   string `json:"shortHash"`
+	Author      string `json:"author,omitempty"`
+	CommittedAt int64  `json:"committedAt,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// ArtifactChangelogSection groups entries of one commit type.
+type ArtifactChangelogSection struct {
+	Type    string                   `json:"type"`
+	Title   string                   `json:"title"`
+	Entries []ArtifactChangelogEntry `json:"entries"`
+}
+
+// ArtifactChangelogRepository holds the changes of one repository.
+type ArtifactChangelogRepository struct {
+	RepositoryID uint64                     `json:"repositoryId"`
+	Name         string                     `json:"name"`
+	Path         string                     `json:"path,omitempty"`
+	FromCommit   string                     `json:"fromCommit,omitempty"`
+	ToCommit     string                     `json:"toCommit,omitempty"`
+	Sections     []ArtifactChangelogSection `json:"sections"`
+}
+
+// ArtifactChangelog is the release notes between two artifacts.
+type ArtifactChangelog struct {
+	Title           string                        `json:"title"`
+	OlderArtifactID uint64                        `json:"olderArtifactId"`
+	NewerArtifactID uint64                        `json:"newerArtifactId"`
+	Repositories    []ArtifactChangelogRepository `json:"repositories"`
+}
+
+// In-toto and SLSA identifiers used by artifact provenance statements.
+const (
+	InTotoStatementType         = "https://in-toto.io/Statement/v1"
+	SLSAProvenancePredicate     = "https://slsa.dev/provenance/v1"
+	InTotoPayloadType           = "application/vnd.in-toto+json"
+	ArtifactProvenanceBuildType = "https://github.com/hujia-team/intranet-sdk/artifact-build@v1"
+)
+
+// InTotoStatement is an in-toto v1 attestation statement.
+type InTotoStatement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     SLSAProvenance       `json:"predicate"`
+}
+
+// ResourceDescriptor identifies an artifact or source by name, URI and digests.
+type ResourceDescriptor struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// SLSAProvenance is the SLSA v1 provenance predicate.
+type SLSAProvenance struct {
+	BuildDefinition SLSABuildDefinition `json:"buildDefinition"`
+	RunDetails      SLSARunDetails      `json:"runDetails"`
+}
+
+// SLSABuildDefinition describes the inputs of a build.
+type SLSABuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   map[string]any       `json:"externalParameters"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+// SLSARunDetails describes the builder and invocation that produced a build.
+type SLSARunDetails struct {
+	Builder  SLSABuilder        `json:"builder"`
+	Metadata *SLSABuildMetadata `json:"metadata,omitempty"`
+}
+
+// SLSABuilder identifies the build platform.
+type SLSABuilder struct {
+	ID string `json:"id"`
+}
+
+// SLSABuildMetadata carries invocation details of a build.
+type SLSABuildMetadata struct {
+	InvocationID string `json:"invocationId,omitempty"`
+	StartedOn    string `json:"startedOn,omitempty"`
+}
+
+// DSSEEnvelope is a signed Dead Simple Signing Envelope.
+type DSSEEnvelope struct {
+	PayloadType string          `json:"payloadType"`
+	Payload     string          `json:"payload"`
+	Signatures  []DSSESignature `json:"signatures"`
+}
+
+// DSSESignature is one signature of a DSSE envelope.
+type DSSESignature struct {
+	KeyID string `json:"keyid,omitempty"`
+	Sig   string `json:"sig"`
+}
+
+// ArtifactProvenanceVerification reports the checks made on a provenance statement.
+type ArtifactProvenanceVerification struct {
+	Verified       bool             `json:"verified"`
+	SignatureValid bool             `json:"signatureValid"`
+	SubjectMatched bool             `json:"subjectMatched"`
+	CommitsMatched bool             `json:"commitsMatched"`
+	MissingCommits []string         `json:"missingCommits,omitempty"`
+	UnknownCommits []string         `json:"unknownCommits,omitempty"`
+	Statement      *InTotoStatement `json:"statement,omitempty"`
+}
+
+// ArtifactSignatureExtraKey is the key under which a detached signature is stored in Extra.
+const ArtifactSignatureExtraKey = "signature"
+
+// ArtifactSignature is a detached ed25519 signature over an artifact file digest.
+type ArtifactSignature struct {
+	Algorithm       string `json:"algorithm"`
+	KeyID           string `json:"keyId"`
+	DigestAlgorithm string `json:"digestAlgorithm"`
+	Digest          string `json:"digest"`
+	Signature       string `json:"signature"`
+	SignedAt        int64  `json:"signedAt,omitempty"`
+}
+
+// ArtifactRetentionReq declares retention rules for artifacts matched by Filter.
+// An artifact is deleted only when no keep rule applies and it is older than
+// MaxAgeDays (or MaxAgeDays is zero). Artifacts with dependents are never deleted.
+type ArtifactRetentionReq struct {
+	Filter     *ArtifactListReq `json:"filter,omitempty"`
+	KeepLast   int              `json:"keepLast,omitempty"`
+	KeepTags   map[string]any   `json:"keepTags,omitempty"`
+	MaxAgeDays int              `json:"maxAgeDays,omitempty"`
+	BatchSize  int              `json:"batchSize,omitempty"`
+	DryRun     bool             `json:"dryRun"`
+}
+
+// Artifact retention actions.
+const (
+	ArtifactRetentionKeep        = "keep"
+	ArtifactRetentionDelete      = "deleted"
+	ArtifactRetentionWouldDelete = "would_delete"
+	ArtifactRetentionFailed      = "failed"
+)
+
+// ArtifactRetentionDecision records what the policy decided for one artifact.
+type ArtifactRetentionDecision struct {
+	ArtifactID uint64   `json:"artifactId"`
+	Name       string   `json:"name,omitempty"`
+	Platform   string   `json:"platform,omitempty"`
+	Action     string   `json:"action"`
+	Reason     string   `json:"reason,omitempty"`
+	Dependents []uint64 `json:"dependents,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// ArtifactRetentionReport summarizes a retention run.
+type ArtifactRetentionReport struct {
+	DryRun      bool                        `json:"dryRun"`
+	Evaluated   int                         `json:"evaluated"`
+	Kept        int                         `json:"kept"`
+	Deleted     int                         `json:"deleted"`
+	WouldDelete int                         `json:"wouldDelete"`
+	Failed      int                         `json:"failed"`
+	Batches     int                         `json:"batches"`
+	Decisions   []ArtifactRetentionDecision `json:"decisions"`
+}
+
+// JfrogFileInfo describes one file listed from JFrog.
+type JfrogFileInfo struct {
+	Repo     string `json:"repo"`
+	Path     string `json:"path"`
+	Name     string `json:"name"`
+	FullPath string `json:"fullPath"`
+	Size     int64  `json:"size,omitempty"`
+	MD5      string `json:"md5,omitempty"`
+	SHA1     string `json:"sha1,omitempty"`
+	SHA256   string `json:"sha256,omitempty"`
+	Created  string `json:"created,omitempty"`
+}
+
+// ArtifactImportReq registers JFrog files of a project that have no
+// artifact yet. Pattern is a JFrog search pattern such as "repo/vision/*".
+// PathConvention infers metadata from the full path with the placeholders
+// {repo}, {name}, {type}, {platform}, {version}, {commit}, {module} and
+// {file}; {*} matches a path segment that is ignored, for example
+// "{repo}/{name}/{platform}/{version}/{file}". Without a convention the name
+// is the file name without its extension. Type is used when the convention
+// has no {type}.
+type ArtifactImportReq struct {
+	ProjectName    string `json:"projectName"`
+	Pattern        string `json:"pattern"`
+	PathConvention string `json:"pathConvention,omitempty"`
+	Type           string `json:"type,omitempty"`
+	DryRun         bool   `json:"dryRun"`
+}
+
+// Artifact import actions.
+const (
+	ArtifactImportTracked     = "tracked"
+	ArtifactImportImported    = "imported"
+	ArtifactImportWouldImport = "would_import"
+	ArtifactImportUnmatched   = "unmatched"
+	ArtifactImportFailed      = "failed"
+)
+
+// ArtifactImportDecision records what the import did with one JFrog file.
+// Artifact is the inferred artifact for imported files and the existing
+// one for tracked files.
+type ArtifactImportDecision struct {
+	File     JfrogFileInfo `json:"file"`
+	Action   string        `json:"action"`
+	Artifact *ArtifactInfo `json:"artifact,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// ArtifactImportReport summarizes an import run.
+type ArtifactImportReport struct {
+	DryRun      bool                     `json:"dryRun"`
+	Scanned     int                      `json:"scanned"`
+	Tracked     int                      `json:"tracked"`
+	Imported    int                      `json:"imported"`
+	WouldImport int                      `json:"wouldImport"`
+	Unmatched   int                      `json:"unmatched"`
+	Failed      int                      `json:"failed"`
+	Decisions   []ArtifactImportDecision `json:"decisions"`
+}
+
+// ArtifactSafeDeleteOptions controls SafeDeleteArtifacts. With Cascade set the
+// dependents of each artifact are deleted as well, dependents first.
+type ArtifactSafeDeleteOptions struct {
+	Cascade bool `json:"cascade"`
+	DryRun  bool `json:"dryRun"`
+}
+
+// ArtifactDeleteBlock lists the live dependents that prevent a deletion.
+type ArtifactDeleteBlock struct {
+	ArtifactID uint64                   `json:"artifactId"`
+	Name       string                   `json:"name,omitempty"`
+	Dependents []ArtifactDependencyInfo `json:"dependents"`
+}
+
+// ArtifactSafeDeleteResult reports what a safe delete removed or why it refused.
+type ArtifactSafeDeleteResult struct {
+	DryRun  bool                  `json:"dryRun"`
+	Deleted []uint64              `json:"deleted"`
+	Blocked []ArtifactDeleteBlock `json:"blocked,omitempty"`
+}
+
+// ArtifactPromotionGate is a precondition checked before entering a stage.
+type ArtifactPromotionGate string
+
+// Supported promotion gates.
+const (
+	// ArtifactPromotionGateSchemaValid requires the promoted tags to validate
+	// against the artifact's tag schema.
+	ArtifactPromotionGateSchemaValid ArtifactPromotionGate = "schema_valid"
+	// ArtifactPromotionGateChecksumPresent requires a FileHash.
+	ArtifactPromotionGateChecksumPresent ArtifactPromotionGate = "checksum_present"
+	// ArtifactPromotionGateDependenciesPromoted requires every artifact in
+	// Dependencies to have reached the target stage or a later one.
+	ArtifactPromotionGateDependenciesPromoted ArtifactPromotionGate = "dependencies_promoted"
+)
+
+// ArtifactPromotionStage defines one stage of a promotion workflow. From lists
+// the stages it may be entered from; an empty From marks an entry stage for
+// artifacts that have not been promoted yet. Tags is merged into the artifact
+// tags as a JSON merge patch when the stage is entered.
+type ArtifactPromotionStage struct {
+	Name  string                  `json:"name"`
+	From  []string                `json:"from,omitempty"`
+	Tags  map[string]any          `json:"tags,omitempty"`
+	Gates []ArtifactPromotionGate `json:"gates,omitempty"`
+}
+
+// ArtifactPromotionWorkflow is an ordered list of stages. StateKey is the
+// Extra key holding the promotion state and defaults to "promotion".
+type ArtifactPromotionWorkflow struct {
+	Stages   []ArtifactPromotionStage `json:"stages"`
+	StateKey string                   `json:"stateKey,omitempty"`
+}
+
+// ArtifactPromotionReq promotes one artifact to a stage.
+type ArtifactPromotionReq struct {
+	Workflow         *ArtifactPromotionWorkflow `json:"workflow"`
+	ArtifactID       uint64                     `json:"artifactId"`
+	ToStage          string                     `json:"toStage"`
+	PromotedBy       string                     `json:"promotedBy"`
+	Note             string                     `json:"note,omitempty"`
+	TagSchemaVersion string                     `json:"tagSchemaVersion,omitempty"`
+}
+
+// ArtifactPromotionRecord is one entry of an artifact's promotion history.
+type ArtifactPromotionRecord struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+	By   string `json:"by"`
+	At   int64  `json:"at"`
+	Note string `json:"note,omitempty"`
+}
+
+// ArtifactPromotionState is stored in the artifact Extra under the workflow
+// StateKey, outside the schema-validated tags.
+type ArtifactPromotionState struct {
+	Stage      string                    `json:"stage"`
+	PromotedBy string                    `json:"promoted_by"`
+	PromotedAt int64                     `json:"promoted_at"`
+	History    []ArtifactPromotionRecord `json:"history,omitempty"`
+}
+
+// ArtifactPromotionResult is the outcome of a promotion.
+type ArtifactPromotionResult struct {
+	ArtifactID uint64                  `json:"artifactId"`
+	Record     ArtifactPromotionRecord `json:"record"`
+	State      ArtifactPromotionState  `json:"state"`
+	Tags       map[string]any          `json:"tags"`
+	Response   *BaseMsgResp            `json:"response,omitempty"`
+}
+
+// ArtifactWatchCheckpoint is the resume position of an artifact watcher.
+// Cursor is the newest change timestamp delivered and SeenIDs lists the
+// artifacts already delivered at exactly that timestamp.
+type ArtifactWatchCheckpoint struct {
+	Cursor  int64    `json:"cursor"`
+	SeenIDs []uint64 `json:"seenIds,omitempty"`
+}
+
+// ArtifactCheckpointStore persists watcher checkpoints across restarts.
+type ArtifactCheckpointStore interface {
+	Load() (*ArtifactWatchCheckpoint, error)
+	Save(checkpoint ArtifactWatchCheckpoint) error
+}
+
+// Artifact watch event types.
+const (
+	ArtifactWatchCreated = "created"
+	ArtifactWatchUpdated = "updated"
+)
+
+// ArtifactWatchEvent delivers one new or updated artifact.
+type ArtifactWatchEvent struct {
+	Type       string                  `json:"type"`
+	Artifact   ArtifactInfo            `json:"artifact"`
+	Checkpoint ArtifactWatchCheckpoint `json:"checkpoint"`
+}
+
+// ArtifactWatchOptions configures an artifact watcher. Without a stored or
+// explicit checkpoint the first poll only records a baseline, unless
+// FromBeginning is set.
+type ArtifactWatchOptions struct {
+	Filter          *ArtifactListReq               `json:"filter,omitempty"`
+	TagsPredicate   func(tags map[string]any) bool `json:"-"`
+	PollInterval    time.Duration                  `json:"pollInterval,omitempty"`
+	MaxBackoff      time.Duration                  `json:"maxBackoff,omitempty"`
+	Checkpoint      *ArtifactWatchCheckpoint       `json:"checkpoint,omitempty"`
+	CheckpointStore ArtifactCheckpointStore        `json:"-"`
+	FromBeginning   bool                           `json:"fromBeginning,omitempty"`
+}
+
+// ArtifactWaitOptions configures how long to wait for an artifact to become
+// available. PollInterval doubles after every miss up to MaxInterval.
+type ArtifactWaitOptions struct {
+	PollInterval time.Duration `json:"pollInterval,omitempty"`
+	MaxInterval  time.Duration `json:"maxInterval,omitempty"`
+	Timeout      time.Duration `json:"timeout,omitempty"`
+}
+
+// ArtifactExpandOptions filters the concrete artifacts a virtual artifact
+// expands to. A nil Platform keeps every platform.
+type ArtifactExpandOptions struct {
+	Platform *string `json:"platform,omitempty"`
+}
+
+// ArtifactDependencyQuery selects dependencies of an artifact. Nil string
+// fields match anything; a pointer to "" matches only empty values. Extra
+// entries must equal the top-level keys of the dependency's Extra metadata,
+// strings ignoring surrounding whitespace, and Match, when set, is applied
+// last to the full artifact detail.
+type ArtifactDependencyQuery struct {
+	Name          *string                           `json:"name,omitempty"`
+	Type          *string                           `json:"type,omitempty"`
+	Platform      *string                           `json:"platform,omitempty"`
+	ModulePath    *string                           `json:"modulePath,omitempty"`
+	Extra         map[string]any                    `json:"extra,omitempty"`
+	ExpandVirtual bool                              `json:"expandVirtual,omitempty"`
+	Match         func(artifact *ArtifactInfo) bool `json:"-"`
+}
+
 // ParseJSON parses a raw JSON string to a generic object.
 func ParseJSON(raw string) (map[string]any, error) {
 	if raw == "" {
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+var conventionalCommitPattern = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// changelogSectionTitles lists known conventional commit types in the order
+// their sections are rendered.
+var changelogSectionTitles = []struct {
+	commitType string
+	title      string
+}{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance"},
+	{"refactor", "Refactoring"},
+	{"revert", "Reverts"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build"},
+	{"ci", "CI"},
+	{"style", "Style"},
+	{"chore", "Chores"},
+	{"other", "Other Changes"},
+}
+
+const defaultChangelogMarkdownTemplate = `# {{.Title}}
+{{range .Repositories}}
+## {{.Name}}
+{{- if and .FromCommit .ToCommit}}
+
+{{short .FromCommit}}...{{short .ToCommit}}
+{{- end}}
+{{range .Sections}}
+### {{.Title}}
+
+{{range .Entries -}}
+- {{if .Breaking}}**BREAKING** {{end}}{{if .Scope}}**{{.Scope}}:** {{end}}{{.Subject}}{{if .ShortHash}} ({{if .URL}}[{{.ShortHash}}]({{.URL}}){{else}}{{.ShortHash}}{{end}}{{if .Author}}, {{.Author}}{{end}}){{end}}
+{{end}}
+{{- end}}
+{{- else}}
+No changes.
+{{end -}}
+`
+
+const defaultChangelogHTMLTemplate = `<h1>{{.Title}}</h1>
+{{- range .Repositories}}
+<h2>{{.Name}}</h2>
+{{- range .Sections}}
+<h3>{{.Title}}</h3>
+<ul>
+{{- range .Entries}}
+<li>{{if .Breaking}}<strong>BREAKING</strong> {{end}}{{if .Scope}}<strong>{{.Scope}}:</strong> {{end}}{{.Subject}}{{if .ShortHash}} ({{if .URL}}<a href="{{.URL}}">{{.ShortHash}}</a>{{else}}{{.ShortHash}}{{end}}{{if .Author}}, {{.Author}}{{end}}){{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- else}}
+<p>No changes.</p>
+{{- end}}
+`
+
+// BuildArtifactChangelog turns the commit diff between two artifacts into
+// release notes grouped by repository and conventional commit type.
+func (s *artifactService) BuildArtifactChangelog(artifactIDA, artifactIDB uint64, options *models.ArtifactChangelogOptions) (*models.ArtifactChangelog, error) {
+	diff, err := s.GetArtifactCommitDiff(artifactIDA, artifactIDB)
+	if err != nil {
+		return nil, err
+	}
+	return BuildChangelogFromCommitDiff(diff, options), nil
+}
+
+// BuildChangelogFromCommitDiff builds a changelog from an already loaded
+// commit diff.
+func BuildChangelogFromCommitDiff(diff *models.ArtifactCommitDiffInfo, options *models.ArtifactChangelogOptions) *models.ArtifactChangelog {
+	if options == nil {
+		options = &models.ArtifactChangelogOptions{}
+	}
+	if diff == nil {
+		diff = &models.ArtifactCommitDiffInfo{}
+	}
+	changelog := &models.ArtifactChangelog{
+		Title:           options.Title,
+		OlderArtifactID: diff.OlderArtifactID,
+		NewerArtifactID: diff.NewerArtifactID,
+		Repositories:    []models.ArtifactChangelogRepository{},
+	}
+	if changelog.Title == "" {
+		changelog.Title = fmt.Sprintf("Changes from artifact %d to %d", diff.OlderArtifactID, diff.NewerArtifactID)
+	}
+
+	for _, repoDiff := range diff.RepoDiffs {
+		repository := models.ArtifactChangelogRepository{
+			RepositoryID: repoDiff.RepositoryID,
+			Name:         valueOrEmpty(repoDiff.RepositoryName),
+			Path:         valueOrEmpty(repoDiff.RepositoryPath),
+		}
+		if repository.Name == "" {
+			repository.Name = repository.Path
+		}
+		if repoDiff.OlderCommit != nil {
+			repository.FromCommit = valueOrEmpty(repoDiff.OlderCommit.CommitHash)
+		}
+		if repoDiff.NewerCommit != nil {
+			repository.ToCommit = valueOrEmpty(repoDiff.NewerCommit.CommitHash)
+		}
+
+		byType := map[string][]models.ArtifactChangelogEntry{}
+		for _, commit := range repoDiff.Commits {
+			entry := parseChangelogEntry(commit)
+			if entry.CommitHash != "" {
+				entry.URL = changelogCommitURL(options, firstNonEmpty(valueOrEmpty(commit.RepositoryPath), repository.Path), entry.CommitHash)
+			}
+			byType[entry.Type] = append(byType[entry.Type], entry)
+		}
+		for _, section := range changelogSectionTitles {
+			if entries := byType[section.commitType]; len(entries) > 0 {
+				repository.Sections = append(repository.Sections, models.ArtifactChangelogSection{
+					Type:    section.commitType,
+					Title:   section.title,
+					Entries: entries,
+				})
+			}
+		}
+		if len(repository.Sections) > 0 {
+			changelog.Repositories = append(changelog.Repositories, repository)
+		}
+	}
+	sort.SliceStable(changelog.Repositories, func(i, j int) bool {
+		return changelog.Repositories[i].Name < changelog.Repositories[j].Name
+	})
+	return changelog
+}
+
+// WriteArtifactChangelog renders a changelog as Markdown, HTML or JSON.
+// options.Template, when set, replaces the built-in Markdown or HTML
+// template; HTML templates are escaped with html/template.
+func WriteArtifactChangelog(changelog *models.ArtifactChangelog, options *models.ArtifactChangelogOptions, w io.Writer) error {
+	if changelog == nil {
+		return utils.NewInvalidInputError("artifact changelog is nil", nil)
+	}
+	if options == nil {
+		options = &models.ArtifactChangelogOptions{}
+	}
+	funcs := map[string]any{"short": shortCommitHash}
+	switch options.Format {
+	case models.ArtifactChangelogMarkdown, "":
+		source := firstNonEmpty(options.Template, defaultChangelogMarkdownTemplate)
+		tmpl, err := texttemplate.New("changelog").Funcs(funcs).Parse(source)
+		if err != nil {
+			return utils.NewInvalidInputError("invalid changelog template", err)
+		}
+		if err := tmpl.Execute(w, changelog); err != nil {
+			return utils.NewInternalError("failed to render changelog", err)
+		}
+	case models.ArtifactChangelogHTML:
+		source := firstNonEmpty(options.Template, defaultChangelogHTMLTemplate)
+		tmpl, err := htmltemplate.New("changelog").Funcs(funcs).Parse(source)
+		if err != nil {
+			return utils.NewInvalidInputError("invalid changelog template", err)
+		}
+		if err := tmpl.Execute(w, changelog); err != nil {
+			return utils.NewInternalError("failed to render changelog", err)
+		}
+	case models.ArtifactChangelogJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(changelog); err != nil {
+			return utils.NewInternalError("failed to encode changelog", err)
+		}
+	default:
+		return utils.NewInvalidInputError(fmt.Sprintf("unsupported changelog format: %s", options.Format), nil)
+	}
+	return nil
+}
+
+// parseChangelogEntry reads the conventional commit header from the commit
+// title, or from the first line of the message when there is no title.
+func parseChangelogEntry(commit models.CommitInfo) models.ArtifactChangelogEntry {
+	message := valueOrEmpty(commit.Message)
+	header := strings.TrimSpace(valueOrEmpty(commit.CommitTitle))
+	if header == "" {
+		header, _, _ = strings.Cut(strings.TrimSpace(message), "\n")
+		header = strings.TrimSpace(header)
+	}
+	entry := models.ArtifactChangelogEntry{
+		Type:        "other",
+		Subject:     header,
+		CommitHash:  valueOrEmpty(commit.CommitHash),
+		ShortHash:   valueOrEmpty(commit.ShortHash),
+		Author:      valueOrEmpty(commit.Author),
+		CommittedAt: int64Value(commit.CommittedAt),
+	}
+	if entry.ShortHash == "" {
+		entry.ShortHash = shortCommitHash(entry.CommitHash)
+	}
+	if match := conventionalCommitPattern.FindStringSubmatch(header); match != nil {
+		commitType := strings.ToLower(match[1])
+		if knownChangelogType(commitType) {
+			entry.Type = commitType
+			entry.Scope = match[2]
+			entry.Breaking = match[3] == "!"
+			entry.Subject = match[4]
+		}
+	}
+	if strings.Contains(message, "BREAKING CHANGE:") || strings.Contains(message, "BREAKING-CHANGE:") {
+		entry.Breaking = true
+	}
+	return entry
+}
+
+func knownChangelogType(commitType string) bool {
+	for _, section := range changelogSectionTitles {
+		if section.commitType == commitType && commitType != "other" {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultChangelogCommitURLTemplate links commits in GitLab's URL layout.
+const defaultChangelogCommitURLTemplate = "{repository}/-/commit/{hash}"
+
+func changelogCommitURL(options *models.ArtifactChangelogOptions, repositoryPath, commitHash string) string {
+	if options.CommitURL != nil {
+		return options.CommitURL(repositoryPath, commitHash)
+	}
+	if repositoryPath == "" {
+		return ""
+	}
+	repository := strings.TrimSuffix(strings.TrimSuffix(repositoryPath, "/"), ".git")
+	if !strings.HasPrefix(repository, "http://") && !strings.HasPrefix(repository, "https://") {
+		if options.RepositoryBaseURL == "" {
+			return ""
+		}
+		repository = strings.TrimSuffix(options.RepositoryBaseURL, "/") + "/" + strings.TrimPrefix(repository, "/")
+	}
+	urlTemplate := options.CommitURLTemplate
+	if urlTemplate == "" {
+		urlTemplate = defaultChangelogCommitURLTemplate
+	}
+	return strings.NewReplacer(
+		"{repository}", repository,
+		"{hash}", commitHash,
+		"{shortHash}", shortCommitHash(commitHash),
+	).Replace(urlTemplate)
+}
+
+func shortCommitHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
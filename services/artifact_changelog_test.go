@@ -0,0 +1,104 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func TestBuildArtifactChangelog(t *testing.T) {
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/aiplorer/artifact/commit-diff" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"code":0,"data":{"olderArtifactId":1,"newerArtifactId":2,"changedRepoCount":2,"repoDiffs":[` +
+			`{"repositoryId":7,"repositoryName":"vision","repositoryPath":"perception/vision",` +
+			`"olderCommit":{"commitHash":"1111111111"},"newerCommit":{"commitHash":"2222222222"},"commits":[` +
+			`{"commitHash":"abcdef0123456","commitTitle":"feat(tracker)!: drop legacy ids","author":"li"},` +
+			`{"commitHash":"bcdef01234567","message":"fix: handle empty frames\n\nBREAKING CHANGE: new output"},` +
+			`{"commitHash":"cdef012345678","shortHash":"cdef012","commitTitle":"Merge branch 'main' <b>"}]},` +
+			`{"repositoryId":8,"repositoryName":"common","repositoryPath":"https://git.example.com/infra/common.git","commits":[` +
+			`{"commitHash":"def0123456789","commitTitle":"docs: update readme"}]}]}}`))
+	})
+
+	changelog, err := service.BuildArtifactChangelog(1, 2, &models.ArtifactChangelogOptions{RepositoryBaseURL: "https://git.example.com/"})
+	if err != nil {
+		t.Fatalf("BuildArtifactChangelog error: %v", err)
+	}
+	if len(changelog.Repositories) != 2 || changelog.Repositories[0].Name != "common" {
+		t.Fatalf("unexpected repositories: %#v", changelog.Repositories)
+	}
+	vision := changelog.Repositories[1]
+	if len(vision.Sections) != 3 || vision.Sections[0].Type != "feat" || vision.Sections[1].Type != "fix" || vision.Sections[2].Type != "other" {
+		t.Fatalf("unexpected sections: %#v", vision.Sections)
+	}
+	feat := vision.Sections[0].Entries[0]
+	if feat.Scope != "tracker" || !feat.Breaking || feat.Subject != "drop legacy ids" || feat.ShortHash != "abcdef01" ||
+		feat.URL != "https://git.example.com/perception/vision/-/commit/abcdef0123456" {
+		t.Fatalf("unexpected feat entry: %#v", feat)
+	}
+	if fix := vision.Sections[1].Entries[0]; !fix.Breaking || fix.Subject != "handle empty frames" {
+		t.Fatalf("unexpected fix entry: %#v", fix)
+	}
+	if docs := changelog.Repositories[0].Sections[0].Entries[0]; docs.URL != "https://git.example.com/infra/common/-/commit/def0123456789" {
+		t.Fatalf("absolute repository paths must be linked as they are: %#v", docs)
+	}
+
+	var markdown bytes.Buffer
+	if err := WriteArtifactChangelog(changelog, nil, &markdown); err != nil {
+		t.Fatalf("WriteArtifactChangelog markdown error: %v", err)
+	}
+	for _, want := range []string{
+		"# Changes from artifact 1 to 2\n",
+		"## vision\n\n11111111...22222222\n",
+		"### Features\n\n- **BREAKING** **tracker:** drop legacy ids ([abcdef01](https://git.example.com/perception/vision/-/commit/abcdef0123456), li)\n",
+		"### Other Changes\n\n- Merge branch 'main' <b> ([cdef012](",
+	} {
+		if !strings.Contains(markdown.String(), want) {
+			t.Fatalf("markdown changelog missing %q:\n%s", want, markdown.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := WriteArtifactChangelog(changelog, &models.ArtifactChangelogOptions{Format: models.ArtifactChangelogHTML}, &html); err != nil {
+		t.Fatalf("WriteArtifactChangelog html error: %v", err)
+	}
+	if !strings.Contains(html.String(), "Merge branch &#39;main&#39; &lt;b&gt;") || !strings.Contains(html.String(), `<h3>Bug Fixes</h3>`) {
+		t.Fatalf("unexpected html changelog:\n%s", html.String())
+	}
+
+	var custom bytes.Buffer
+	options := &models.ArtifactChangelogOptions{Template: `{{range .Repositories}}{{.Name}}:{{len .Sections}};{{end}}`}
+	if err := WriteArtifactChangelog(changelog, options, &custom); err != nil || custom.String() != "common:1;vision:3;" {
+		t.Fatalf("custom template rendered %q, err %v", custom.String(), err)
+	}
+
+	var encoded bytes.Buffer
+	if err := WriteArtifactChangelog(changelog, &models.ArtifactChangelogOptions{Format: models.ArtifactChangelogJSON}, &encoded); err != nil {
+		t.Fatalf("WriteArtifactChangelog json error: %v", err)
+	}
+	var decoded models.ArtifactChangelog
+	if err := json.Unmarshal(encoded.Bytes(), &decoded); err != nil || len(decoded.Repositories) != 2 {
+		t.Fatalf("unexpected json changelog: %v\n%s", err, encoded.String())
+	}
+}
+
+func TestBuildChangelogFromCommitDiffURLTemplateAndNilDiff(t *testing.T) {
+	diff := &models.ArtifactCommitDiffInfo{RepoDiffs: []models.RepoDiff{{
+		RepositoryPath: stringPtr("https://github.com/acme/vision.git"),
+		Commits:        []models.CommitInfo{{CommitHash: stringPtr("abcdef0123456"), CommitTitle: stringPtr("feat: track")}},
+	}}}
+	changelog := BuildChangelogFromCommitDiff(diff, &models.ArtifactChangelogOptions{CommitURLTemplate: "{repository}/commit/{hash}?s={shortHash}"})
+	if url := changelog.Repositories[0].Sections[0].Entries[0].URL; url != "https://github.com/acme/vision/commit/abcdef0123456?s=abcdef01" {
+		t.Fatalf("unexpected templated commit url: %s", url)
+	}
+
+	empty := BuildChangelogFromCommitDiff(nil, nil)
+	if empty == nil || len(empty.Repositories) != 0 {
+		t.Fatalf("a nil diff must give an empty changelog: %#v", empty)
+	}
+}
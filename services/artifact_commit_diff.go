@@ -0,0 +1,125 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+// GetArtifactCommitDiffByCommitHash resolves both commit hashes to artifacts
+// with the lookup options and returns their commit diff.
+func (s *artifactService) GetArtifactCommitDiffByCommitHash(commitHashA, commitHashB string, lookup *models.ArtifactLookupOptions) (*models.ArtifactCommitDiffInfo, error) {
+	return s.diffArtifactBuildRefs("", lookup,
+		models.ArtifactBuildRef{CommitHash: commitHashA},
+		models.ArtifactBuildRef{CommitHash: commitHashB})
+}
+
+// GetArtifactCommitDiffByVersion resolves two semantic versions of the named
+// artifact and returns their commit diff. lookup.SemanticVersion is ignored.
+func (s *artifactService) GetArtifactCommitDiffByVersion(name, versionA, versionB string, lookup *models.ArtifactLookupOptions) (*models.ArtifactCommitDiffInfo, error) {
+	return s.diffArtifactBuildRefs(name, lookup,
+		models.ArtifactBuildRef{SemanticVersion: versionA},
+		models.ArtifactBuildRef{SemanticVersion: versionB})
+}
+
+// GetArtifactCommitDiffSeries diffs every pair of consecutive builds and
+// aggregates the commits of all steps.
+func (s *artifactService) GetArtifactCommitDiffSeries(req *models.ArtifactCommitDiffSeriesReq) (*models.ArtifactCommitDiffSeries, error) {
+	if req == nil || len(req.Builds) < 2 {
+		return nil, utils.NewInvalidInputError("at least two builds are required for a commit diff series", nil)
+	}
+	ids := make([]uint64, 0, len(req.Builds))
+	for _, ref := range req.Builds {
+		id, err := s.resolveArtifactBuildRef(req.Name, req.Lookup, ref)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	series := &models.ArtifactCommitDiffSeries{
+		Steps:   make([]models.ArtifactCommitDiffInfo, 0, len(ids)-1),
+		Commits: []models.CommitInfo{},
+	}
+	seen := map[string]bool{}
+	for i := 1; i < len(ids); i++ {
+		diff, err := s.GetArtifactCommitDiff(ids[i-1], ids[i])
+		if err != nil {
+			return nil, err
+		}
+		series.Steps = append(series.Steps, *diff)
+		for _, repoDiff := range diff.RepoDiffs {
+			for _, commit := range repoDiff.Commits {
+				if commit.RepositoryID == nil && repoDiff.RepositoryID != 0 {
+					repositoryID := repoDiff.RepositoryID
+					commit.RepositoryID = &repositoryID
+				}
+				if commit.RepositoryName == nil {
+					commit.RepositoryName = repoDiff.RepositoryName
+				}
+				if commit.RepositoryPath == nil {
+					commit.RepositoryPath = repoDiff.RepositoryPath
+				}
+				key := fmt.Sprintf("%d/%s", repoDiff.RepositoryID, valueOrEmpty(commit.CommitHash))
+				if commit.CommitHash == nil || seen[key] {
+					continue
+				}
+				seen[key] = true
+				series.Commits = append(series.Commits, commit)
+			}
+		}
+	}
+	return series, nil
+}
+
+func (s *artifactService) diffArtifactBuildRefs(name string, lookup *models.ArtifactLookupOptions, a, b models.ArtifactBuildRef) (*models.ArtifactCommitDiffInfo, error) {
+	idA, err := s.resolveArtifactBuildRef(name, lookup, a)
+	if err != nil {
+		return nil, err
+	}
+	idB, err := s.resolveArtifactBuildRef(name, lookup, b)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetArtifactCommitDiff(idA, idB)
+}
+
+func (s *artifactService) resolveArtifactBuildRef(name string, lookup *models.ArtifactLookupOptions, ref models.ArtifactBuildRef) (uint64, error) {
+	set := 0
+	for _, present := range []bool{ref.ID != 0, ref.CommitHash != "", ref.SemanticVersion != ""} {
+		if present {
+			set++
+		}
+	}
+	if set != 1 {
+		return 0, utils.NewInvalidInputError("exactly one of id, commit hash or semantic version is required per build", nil)
+	}
+
+	var artifact *models.ArtifactInfo
+	var err error
+	switch {
+	case ref.ID != 0:
+		return ref.ID, nil
+	case ref.CommitHash != "":
+		artifact, err = s.GetArtifactByCommitHash(strings.TrimSpace(ref.CommitHash), lookup)
+	default:
+		if strings.TrimSpace(name) == "" {
+			return 0, utils.NewInvalidInputError("artifact name is required to resolve a semantic version", nil)
+		}
+		var versionLookup models.ArtifactLookupOptions
+		if lookup != nil {
+			versionLookup = *lookup
+		}
+		versionLookup.SemanticVersion = strings.TrimSpace(ref.SemanticVersion)
+		artifact, err = s.GetArtifactByName(name, &versionLookup)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if artifact.ID == nil {
+		return 0, utils.NewAPIError(fmt.Sprintf("artifact id missing for build %s%s", ref.CommitHash, ref.SemanticVersion), nil)
+	}
+	return *artifact.ID, nil
+}
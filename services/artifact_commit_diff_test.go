@@ -0,0 +1,78 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func TestGetArtifactCommitDiffByRefs(t *testing.T) {
+	var diffs [][2]float64
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeBody(t, r)
+		switch r.URL.Path {
+		case "/aiplorer/artifact/by-commit-hash":
+			ids := map[string]int{"aaa": 1, "bbb": 2}
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"code":0,"data":{"id":%d}}`, ids[payload["commitHash"].(string)])))
+		case "/aiplorer/artifact/list":
+			if payload["name"] != "vision" || payload["platform"] != "x9" {
+				t.Fatalf("unexpected list request: %#v", payload)
+			}
+			ids := map[string]int{"1.0.0": 3, "1.1.0": 4}
+			id := ids[payload["semanticVersion"].(string)]
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"code":0,"data":{"total":1,"data":[{"id":%d,"name":"vision"}]}}`, id)))
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":` + mustJSON(payload["id"]) + `}}`))
+		case "/aiplorer/artifact/commit-diff":
+			a, b := payload["artifactIdA"].(float64), payload["artifactIdB"].(float64)
+			diffs = append(diffs, [2]float64{a, b})
+			shared := `{"commitHash":"c1","commitTitle":"fix: shared"}`
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"code":0,"data":{"olderArtifactId":%v,"newerArtifactId":%v,"repoDiffs":[`+
+				`{"repositoryId":7,"repositoryName":"vision","commits":[{"commitHash":"c%v"},%s]}]}}`, a, b, b, shared)))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	if _, err := service.GetArtifactCommitDiffByCommitHash("aaa", "bbb", nil); err != nil {
+		t.Fatalf("GetArtifactCommitDiffByCommitHash error: %v", err)
+	}
+	platform := "x9"
+	lookup := &models.ArtifactLookupOptions{Platform: &platform, SemanticVersion: "9.9.9"}
+	if _, err := service.GetArtifactCommitDiffByVersion("vision", "1.0.0", "1.1.0", lookup); err != nil {
+		t.Fatalf("GetArtifactCommitDiffByVersion error: %v", err)
+	}
+	if len(diffs) != 2 || diffs[0] != [2]float64{1, 2} || diffs[1] != [2]float64{3, 4} {
+		t.Fatalf("unexpected diff requests: %v", diffs)
+	}
+
+	series, err := service.GetArtifactCommitDiffSeries(&models.ArtifactCommitDiffSeriesReq{
+		Name:   "vision",
+		Lookup: lookup,
+		Builds: []models.ArtifactBuildRef{{ID: 1}, {CommitHash: "bbb"}, {SemanticVersion: "1.0.0"}},
+	})
+	if err != nil {
+		t.Fatalf("GetArtifactCommitDiffSeries error: %v", err)
+	}
+	if len(series.Steps) != 2 || series.Steps[1].OlderArtifactID != 2 || series.Steps[1].NewerArtifactID != 3 {
+		t.Fatalf("unexpected series steps: %#v", series.Steps)
+	}
+	var hashes []string
+	for _, commit := range series.Commits {
+		hashes = append(hashes, *commit.CommitHash)
+		if commit.RepositoryID == nil || *commit.RepositoryID != 7 || valueOrEmpty(commit.RepositoryName) != "vision" {
+			t.Fatalf("series commits must carry their repository: %#v", commit)
+		}
+	}
+	if fmt.Sprint(hashes) != "[c2 c1 c3]" {
+		t.Fatalf("unexpected aggregated commits: %v", hashes)
+	}
+
+	if _, err := service.GetArtifactCommitDiffSeries(&models.ArtifactCommitDiffSeriesReq{
+		Builds: []models.ArtifactBuildRef{{ID: 1}, {ID: 2, CommitHash: "bbb"}},
+	}); err == nil {
+		t.Fatal("builds with several identifiers must be rejected")
+	}
+}
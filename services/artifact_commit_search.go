@@ -0,0 +1,125 @@
+package services
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+const (
+	minCommitSearchHashLength = 4
+	fullCommitHashLength      = 40
+)
+
+// FindArtifactsContainingCommit scans artifacts for a commit hash or short
+// hash, in their own commits and, unless DirectOnly is set, in the commits of
+// all their dependencies.
+func (s *artifactService) FindArtifactsContainingCommit(req *models.ArtifactCommitSearchReq) (*models.ArtifactCommitSearchResult, error) {
+	if req == nil {
+		return nil, utils.NewInvalidInputError("commit search request is nil", nil)
+	}
+	hash := strings.ToLower(strings.TrimSpace(req.CommitHash))
+	if len(hash) < minCommitSearchHashLength {
+		return nil, utils.NewInvalidInputError("commit hash must have at least 4 characters", nil)
+	}
+
+	listReq := buildNameLookupListRequest("", req.Lookup)
+	if req.DirectOnly {
+		// The list API matches the artifact's own commit hash, which is all a
+		// direct search needs.
+		listReq.CommitHash = &hash
+		exact := len(hash) == fullCommitHashLength
+		listReq.ExactCommitHash = &exact
+	}
+
+	result := &models.ArtifactCommitSearchResult{
+		CommitHash:      hash,
+		Matches:         []models.ArtifactCommitMatch{},
+		FirstByPlatform: map[string]models.ArtifactCommitMatch{},
+	}
+	err := s.forEachArtifact(listReq, func(item models.ArtifactInfo) (bool, error) {
+		if item.ID != nil && item.Commits == nil && item.Dependencies == nil {
+			detail, err := s.GetArtifactByID(*item.ID)
+			if err != nil {
+				return false, err
+			}
+			item = *detail
+		}
+		if match, ok := matchArtifactCommit(item, hash, req.DirectOnly); ok {
+			result.Matches = append(result.Matches, match)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result.Matches, func(i, j int) bool {
+		a, b := result.Matches[i].Artifact, result.Matches[j].Artifact
+		if order := cmp.Compare(artifactTimestamp(a), artifactTimestamp(b)); order != 0 {
+			return order < 0
+		}
+		return uint64Value(a.ID) < uint64Value(b.ID)
+	})
+	for _, match := range result.Matches {
+		platform := valueOrEmpty(match.Artifact.Platform)
+		if _, ok := result.FirstByPlatform[platform]; !ok {
+			result.FirstByPlatform[platform] = match
+		}
+	}
+	return result, nil
+}
+
+func matchArtifactCommit(item models.ArtifactInfo, hash string, directOnly bool) (models.ArtifactCommitMatch, bool) {
+	match := models.ArtifactCommitMatch{Artifact: item}
+	if commit := findCommit(item.Commits, hash); commit != nil {
+		match.Commit = commit
+		return match, true
+	}
+	if commitHashMatches(valueOrEmpty(item.CommitHash), hash) {
+		return match, true
+	}
+	if directOnly {
+		return match, false
+	}
+	for i := range item.Dependencies {
+		dependency := &item.Dependencies[i]
+		commit := findCommit(dependency.Commits, hash)
+		if commit != nil || commitHashMatches(valueOrEmpty(dependency.CommitHash), hash) {
+			match.Commit = commit
+			match.Dependency = dependency
+			return match, true
+		}
+	}
+	return match, false
+}
+
+func findCommit(commits []models.CommitInfo, hash string) *models.CommitInfo {
+	for i := range commits {
+		if commitHashMatches(valueOrEmpty(commits[i].CommitHash), hash) ||
+			commitHashMatches(valueOrEmpty(commits[i].ShortHash), hash) {
+			return &commits[i]
+		}
+	}
+	return nil
+}
+
+// commitHashMatches reports whether a recorded hash and the searched hash
+// name the same commit, allowing either side to be abbreviated.
+func commitHashMatches(recorded, hash string) bool {
+	recorded = strings.ToLower(strings.TrimSpace(recorded))
+	if len(recorded) < minCommitSearchHashLength {
+		return false
+	}
+	return strings.HasPrefix(recorded, hash) || strings.HasPrefix(hash, recorded)
+}
+
+func uint64Value(value *uint64) uint64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
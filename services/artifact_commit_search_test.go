@@ -0,0 +1,71 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func TestFindArtifactsContainingCommit(t *testing.T) {
+	const hash = "abcdef0123456789abcdef0123456789abcdef01"
+	var detailRequests int
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeBody(t, r)
+		switch r.URL.Path {
+		case "/aiplorer/artifact/list":
+			if payload["platform"] != nil {
+				t.Fatalf("unexpected server-side filter: %#v", payload)
+			}
+			if payload["commitHash"] != nil {
+				if payload["commitHash"] != "abcdef0" || payload["exactCommitHash"] != false {
+					t.Fatalf("unexpected direct search filter: %#v", payload)
+				}
+				_, _ = w.Write([]byte(`{"code":0,"data":{"total":1,"data":[` +
+					`{"id":5,"platform":"x9","buildDate":500,"commitHash":"` + hash + `","commits":[]}]}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":0,"data":{"total":5,"data":[` +
+				`{"id":1,"platform":"x9","buildDate":100,"commitHash":"0000000","commits":[{"commitHash":"1111111"}]},` +
+				`{"id":2,"platform":"x9","buildDate":300,"commitHash":"2222222","dependencies":[` +
+				`{"id":20,"name":"lib","commitHash":"3333333"},{"id":21,"name":"proto","parentId":20,"commitHash":"4444444","commits":[{"commitHash":"` + hash + `"}]}]},` +
+				`{"id":3,"platform":"x9","buildDate":200,"commits":[{"shortHash":"abcdef0"}]},` +
+				`{"id":4,"platform":"j5","buildDate":400},` +
+				`{"id":6,"platform":"j5","buildDate":600,"commitHash":"` + hash + `","commits":[]}]}}`))
+		case "/aiplorer/artifact":
+			detailRequests++
+			if payload["id"].(float64) != 4 {
+				t.Fatalf("only artifacts without lineage need their detail, got %v", payload["id"])
+			}
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":4,"platform":"j5","buildDate":400,"dependencies":[{"id":40,"name":"lib","commitHash":"ABCDEF0123"}]}}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	result, err := service.FindArtifactsContainingCommit(&models.ArtifactCommitSearchReq{CommitHash: "ABCDEF0"})
+	if err != nil {
+		t.Fatalf("FindArtifactsContainingCommit error: %v", err)
+	}
+	var ids []uint64
+	for _, match := range result.Matches {
+		ids = append(ids, *match.Artifact.ID)
+	}
+	if len(ids) != 4 || ids[0] != 3 || ids[1] != 2 || ids[2] != 4 || ids[3] != 6 || detailRequests != 1 {
+		t.Fatalf("unexpected matches %v (detail requests %d)", ids, detailRequests)
+	}
+	if dep := result.Matches[1].Dependency; dep == nil || *dep.Name != "proto" || result.Matches[1].Commit == nil {
+		t.Fatalf("transitive dependency commits must be reported: %#v", result.Matches[1])
+	}
+	if *result.FirstByPlatform["x9"].Artifact.ID != 3 || *result.FirstByPlatform["j5"].Artifact.ID != 4 {
+		t.Fatalf("unexpected first builds: %#v", result.FirstByPlatform)
+	}
+
+	direct, err := service.FindArtifactsContainingCommit(&models.ArtifactCommitSearchReq{CommitHash: "abcdef0", DirectOnly: true})
+	if err != nil || len(direct.Matches) != 1 || *direct.Matches[0].Artifact.ID != 5 {
+		t.Fatalf("unexpected direct search: %#v %v", direct, err)
+	}
+	if _, err := service.FindArtifactsContainingCommit(&models.ArtifactCommitSearchReq{CommitHash: "ab"}); err == nil {
+		t.Fatal("too short hashes must be rejected")
+	}
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+// CompareArtifacts loads two artifacts and returns how metadata, tags, Extra
+// and the dependency set changed from A to B.
+func (s *artifactService) CompareArtifacts(artifactIDA, artifactIDB uint64) (*models.ArtifactDiff, error) {
+	a, err := s.GetArtifactByID(artifactIDA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.GetArtifactByID(artifactIDB)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := DiffArtifacts(a, b)
+	if err != nil {
+		return nil, err
+	}
+	diff.ArtifactIDA, diff.ArtifactIDB = artifactIDA, artifactIDB
+	return diff, nil
+}
+
+// DiffArtifacts compares two loaded artifacts.
+func DiffArtifacts(a, b *models.ArtifactInfo) (*models.ArtifactDiff, error) {
+	if a == nil || b == nil {
+		return nil, utils.NewInvalidInputError("both artifacts are required for a diff", nil)
+	}
+	diff := &models.ArtifactDiff{}
+	if a.ID != nil {
+		diff.ArtifactIDA = *a.ID
+	}
+	if b.ID != nil {
+		diff.ArtifactIDB = *b.ID
+	}
+
+	fields := []struct {
+		name   string
+		before any
+		after  any
+	}{
+		{"name", a.Name, b.Name},
+		{"type", a.Type, b.Type},
+		{"platform", a.Platform, b.Platform},
+		{"semanticVersion", a.SemanticVersion, b.SemanticVersion},
+		{"commitHash", a.CommitHash, b.CommitHash},
+		{"fileHash", a.FileHash, b.FileHash},
+		{"fullPath", a.FullPath, b.FullPath},
+		{"projectName", a.ProjectName, b.ProjectName},
+		{"modulePath", a.ModulePath, b.ModulePath},
+		{"isVirtual", a.IsVirtual, b.IsVirtual},
+		{"tagSchemaVersion", a.TagSchemaVersion, b.TagSchemaVersion},
+		{"pipelineId", a.PipelineID, b.PipelineID},
+		{"buildDate", a.BuildDate, b.BuildDate},
+	}
+	for _, field := range fields {
+		diff.Metadata = appendValueChange(diff.Metadata, field.name, derefValue(field.before), derefValue(field.after))
+	}
+
+	tagsA, err := artifactTagsMap(a)
+	if err != nil {
+		return nil, err
+	}
+	tagsB, err := artifactTagsMap(b)
+	if err != nil {
+		return nil, err
+	}
+	diff.Tags = diffJSONValues("", tagsA, tagsB, nil)
+
+	extraA, err := artifactExtraMap(a)
+	if err != nil {
+		return nil, err
+	}
+	extraB, err := artifactExtraMap(b)
+	if err != nil {
+		return nil, err
+	}
+	diff.Extra = diffJSONValues("", extraA, extraB, nil)
+
+	diff.Dependencies = diffArtifactDependencies(a.Dependencies, b.Dependencies)
+	return diff, nil
+}
+
+// WriteArtifactDiff renders a diff as human-readable text or indented JSON.
+func WriteArtifactDiff(diff *models.ArtifactDiff, format models.ArtifactDiffFormat, w io.Writer) error {
+	if diff == nil {
+		return utils.NewInvalidInputError("artifact diff is nil", nil)
+	}
+	switch format {
+	case models.ArtifactDiffJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diff); err != nil {
+			return utils.NewInternalError("failed to encode artifact diff", err)
+		}
+		return nil
+	case models.ArtifactDiffText, "":
+		return writeArtifactDiffText(diff, w)
+	default:
+		return utils.NewInvalidInputError(fmt.Sprintf("unsupported artifact diff format: %s", format), nil)
+	}
+}
+
+func writeArtifactDiffText(diff *models.ArtifactDiff, w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "artifact %d -> %d\n", diff.ArtifactIDA, diff.ArtifactIDB)
+	if len(diff.Metadata)+len(diff.Tags)+len(diff.Extra)+len(diff.Dependencies) == 0 {
+		fmt.Fprintln(out, "no differences")
+	}
+	for _, section := range []struct {
+		title   string
+		changes []models.ArtifactValueChange
+	}{
+		{"metadata", diff.Metadata},
+		{"tags", diff.Tags},
+		{"extra", diff.Extra},
+	} {
+		if len(section.changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "%s:\n", section.title)
+		for _, change := range section.changes {
+			switch change.Kind {
+			case models.ArtifactChangeAdded:
+				fmt.Fprintf(out, "  + %s: %s\n", change.Path, diffValueText(change.New))
+			case models.ArtifactChangeRemoved:
+				fmt.Fprintf(out, "  - %s: %s\n", change.Path, diffValueText(change.Old))
+			default:
+				fmt.Fprintf(out, "  ~ %s: %s -> %s\n", change.Path, diffValueText(change.Old), diffValueText(change.New))
+			}
+		}
+	}
+	if len(diff.Dependencies) > 0 {
+		fmt.Fprintln(out, "dependencies:")
+		for _, change := range diff.Dependencies {
+			switch change.Kind {
+			case models.ArtifactChangeAdded:
+				fmt.Fprintf(out, "  + %s %s\n", change.Key, dependencyPin(change.New))
+			case models.ArtifactChangeRemoved:
+				fmt.Fprintf(out, "  - %s %s\n", change.Key, dependencyPin(change.Old))
+			default:
+				fmt.Fprintf(out, "  ~ %s %s -> %s\n", change.Key, dependencyPin(change.Old), dependencyPin(change.New))
+			}
+		}
+	}
+	if err := out.Flush(); err != nil {
+		return utils.NewInternalError("failed to write artifact diff", err)
+	}
+	return nil
+}
+
+// diffJSONValues walks two decoded JSON documents and reports changes by
+// JSON pointer. Objects are compared key by key; arrays and scalars as
+// whole values.
+func diffJSONValues(path string, before, after any, changes []models.ArtifactValueChange) []models.ArtifactValueChange {
+	beforeObject, beforeIsObject := before.(map[string]any)
+	afterObject, afterIsObject := after.(map[string]any)
+	if !beforeIsObject || !afterIsObject {
+		return appendValueChange(changes, pathOrRoot(path), before, after)
+	}
+	keys := make([]string, 0, len(beforeObject)+len(afterObject))
+	for key := range beforeObject {
+		keys = append(keys, key)
+	}
+	for key := range afterObject {
+		if _, ok := beforeObject[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		childPath := path + "/" + escapeJSONPointerToken(key)
+		beforeValue, inBefore := beforeObject[key]
+		afterValue, inAfter := afterObject[key]
+		switch {
+		case !inBefore:
+			changes = append(changes, models.ArtifactValueChange{Path: childPath, Kind: models.ArtifactChangeAdded, New: afterValue})
+		case !inAfter:
+			changes = append(changes, models.ArtifactValueChange{Path: childPath, Kind: models.ArtifactChangeRemoved, Old: beforeValue})
+		default:
+			changes = diffJSONValues(childPath, beforeValue, afterValue, changes)
+		}
+	}
+	return changes
+}
+
+func appendValueChange(changes []models.ArtifactValueChange, path string, before, after any) []models.ArtifactValueChange {
+	if reflect.DeepEqual(before, after) {
+		return changes
+	}
+	change := models.ArtifactValueChange{Path: path, Kind: models.ArtifactChangeModified, Old: before, New: after}
+	switch {
+	case before == nil:
+		change.Kind = models.ArtifactChangeAdded
+	case after == nil:
+		change.Kind = models.ArtifactChangeRemoved
+	}
+	return append(changes, change)
+}
+
+func diffArtifactDependencies(before, after []models.ArtifactDependencyInfo) []models.ArtifactDependencyChange {
+	beforeByKey := indexDependencies(before)
+	afterByKey := indexDependencies(after)
+	keys := make([]string, 0, len(beforeByKey)+len(afterByKey))
+	for key := range beforeByKey {
+		keys = append(keys, key)
+	}
+	for key := range afterByKey {
+		if _, ok := beforeByKey[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []models.ArtifactDependencyChange
+	for _, key := range keys {
+		old, inBefore := beforeByKey[key]
+		current, inAfter := afterByKey[key]
+		switch {
+		case !inBefore:
+			changes = append(changes, models.ArtifactDependencyChange{Key: key, Kind: models.ArtifactChangeAdded, New: current})
+		case !inAfter:
+			changes = append(changes, models.ArtifactDependencyChange{Key: key, Kind: models.ArtifactChangeRemoved, Old: old})
+		case valueOrEmpty(old.CommitHash) != valueOrEmpty(current.CommitHash) || !sameID(old.ID, current.ID):
+			changes = append(changes, models.ArtifactDependencyChange{Key: key, Kind: models.ArtifactChangeRepinned, Old: old, New: current})
+		}
+	}
+	return changes
+}
+
+// indexDependencies keys dependencies by identity, keeping the first
+// occurrence when the same child appears under several parents.
+func indexDependencies(dependencies []models.ArtifactDependencyInfo) map[string]*models.ArtifactDependencyInfo {
+	index := make(map[string]*models.ArtifactDependencyInfo, len(dependencies))
+	for i := range dependencies {
+		key := dependencyKey(dependencies[i])
+		if _, ok := index[key]; !ok {
+			index[key] = &dependencies[i]
+		}
+	}
+	return index
+}
+
+func dependencyKey(dependency models.ArtifactDependencyInfo) string {
+	key := valueOrEmpty(dependency.Name)
+	qualifiers := []string{}
+	for _, value := range []string{valueOrEmpty(dependency.Type), valueOrEmpty(dependency.Platform), valueOrEmpty(dependency.ModulePath)} {
+		if value != "" {
+			qualifiers = append(qualifiers, value)
+		}
+	}
+	if len(qualifiers) > 0 {
+		key += " (" + strings.Join(qualifiers, ", ") + ")"
+	}
+	return key
+}
+
+func dependencyPin(dependency *models.ArtifactDependencyInfo) string {
+	if dependency == nil {
+		return ""
+	}
+	pin := valueOrEmpty(dependency.CommitHash)
+	if dependency.ID != nil {
+		pin = fmt.Sprintf("%s#%d", pin, *dependency.ID)
+	}
+	return pin
+}
+
+func sameID(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func derefValue(value any) any {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Pointer {
+		return value
+	}
+	if rv.IsNil() {
+		return nil
+	}
+	return rv.Elem().Interface()
+}
+
+func diffValueText(value any) string {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(raw)
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func escapeJSONPointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
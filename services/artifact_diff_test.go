@@ -0,0 +1,73 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func TestCompareArtifacts(t *testing.T) {
+	details := map[float64]string{
+		1: `{"id":1,"name":"vision","platform":"x9","semanticVersion":"1.2.0",` +
+			`"tags":"{\"decision\":\"pending\",\"basis\":{\"score\":1,\"owner\":\"a\"}}","extra":"{\"artifact_type\":\"app\"}",` +
+			`"dependencies":[{"id":10,"name":"lib","type":"app","platform":"x9","commitHash":"aaa"},{"id":11,"name":"proto","type":"app","commitHash":"bbb"}]}`,
+		2: `{"id":2,"name":"vision","platform":"x9","semanticVersion":"1.3.0",` +
+			`"tags":"{\"decision\":\"pass\",\"basis\":{\"score\":1}}","extra":"{\"artifact_type\":\"app\",\"a/b\":true}",` +
+			`"dependencies":[{"id":12,"name":"lib","type":"app","platform":"x9","commitHash":"ccc"},{"id":13,"name":"msg","type":"app","commitHash":"ddd"}]}`,
+	}
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":0,"data":` + details[decodeBody(t, r)["id"].(float64)] + `}`))
+	})
+
+	diff, err := service.CompareArtifacts(1, 2)
+	if err != nil {
+		t.Fatalf("CompareArtifacts error: %v", err)
+	}
+	if len(diff.Metadata) != 1 || diff.Metadata[0].Path != "semanticVersion" || diff.Metadata[0].New != "1.3.0" {
+		t.Fatalf("unexpected metadata diff: %#v", diff.Metadata)
+	}
+	if len(diff.Tags) != 2 || diff.Tags[0].Path != "/basis/owner" || diff.Tags[0].Kind != models.ArtifactChangeRemoved ||
+		diff.Tags[1].Path != "/decision" || diff.Tags[1].Kind != models.ArtifactChangeModified {
+		t.Fatalf("unexpected tags diff: %#v", diff.Tags)
+	}
+	if len(diff.Extra) != 1 || diff.Extra[0].Path != "/a~1b" || diff.Extra[0].Kind != models.ArtifactChangeAdded {
+		t.Fatalf("unexpected extra diff: %#v", diff.Extra)
+	}
+	kinds := map[string]string{}
+	for _, change := range diff.Dependencies {
+		kinds[change.Key] = change.Kind
+	}
+	if len(kinds) != 3 || kinds["lib (app, x9)"] != models.ArtifactChangeRepinned ||
+		kinds["proto (app)"] != models.ArtifactChangeRemoved || kinds["msg (app)"] != models.ArtifactChangeAdded {
+		t.Fatalf("unexpected dependency diff: %#v", diff.Dependencies)
+	}
+
+	var text bytes.Buffer
+	if err := WriteArtifactDiff(diff, models.ArtifactDiffText, &text); err != nil {
+		t.Fatalf("WriteArtifactDiff text error: %v", err)
+	}
+	for _, want := range []string{
+		"artifact 1 -> 2",
+		`  ~ semanticVersion: "1.2.0" -> "1.3.0"`,
+		`  - /basis/owner: "a"`,
+		"  ~ lib (app, x9) aaa#10 -> ccc#12",
+		"  + msg (app) ddd#13",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Fatalf("text diff missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var encoded bytes.Buffer
+	if err := WriteArtifactDiff(diff, models.ArtifactDiffJSON, &encoded); err != nil {
+		t.Fatalf("WriteArtifactDiff json error: %v", err)
+	}
+	var decoded models.ArtifactDiff
+	if err := json.Unmarshal(encoded.Bytes(), &decoded); err != nil || len(decoded.Dependencies) != 3 {
+		t.Fatalf("unexpected json diff: %v\n%s", err, encoded.String())
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+// downloadPlanRefreshMargin treats credentials that expire within this
+// window as already stale, so a download does not start on a URL or token
+// about to lapse.
+const downloadPlanRefreshMargin = time.Minute
+
+var downloadURLExpireTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+}
+
+// ExecuteDownloadPlan downloads a plan prepared earlier with one of the
+// PrepareDownload methods. Stale plans are refreshed first and updated in
+// place, so retrying an interrupted download reuses the refreshed URL and
+// token.
+func (s *artifactService) ExecuteDownloadPlan(plan *models.ArtifactDownloadPlan) (*models.ArtifactDownloadPlan, error) {
+	if plan == nil {
+		return nil, utils.NewInvalidInputError("download plan is nil", nil)
+	}
+	return s.executeDownloadPlan(plan)
+}
+
+// RefreshDownloadPlan fetches a new download URL and JFrog token for the
+// plan's artifact and clears its Stale flag.
+func (s *artifactService) RefreshDownloadPlan(plan *models.ArtifactDownloadPlan) error {
+	if plan == nil || plan.Artifact == nil || plan.Artifact.ID == nil {
+		return utils.NewInvalidInputError("download plan has no artifact to refresh", nil)
+	}
+	projectName := valueOrEmpty(plan.Artifact.ProjectName)
+	if projectName == "" {
+		return utils.NewAPIError(fmt.Sprintf("artifact project_name is empty for artifact id: %d", *plan.Artifact.ID), nil)
+	}
+	utils.Debug("Refreshing download plan for artifact %d", *plan.Artifact.ID)
+	if plan.Token != nil && expiresWithinMargin(plan.Token.ExpiresAt, time.Now()) {
+		s.jfrogTokens.invalidate(projectName, plan.Token)
+	}
+	token, err := s.GetJfrogToken(projectName)
+	if err != nil {
+		return err
+	}
+	downloadURL, err := s.GetArtifactDownloadURL(*plan.Artifact.ID, "artifact")
+	if err != nil {
+		return err
+	}
+	plan.Token = token
+	plan.DownloadURL = downloadURL
+	plan.Stale = false
+	return nil
+}
+
+// IsDownloadPlanStale reports whether the plan's token or download URL has
+// expired, or will within a minute of now. Unknown expiries never make a
+// plan stale.
+func IsDownloadPlanStale(plan *models.ArtifactDownloadPlan, now time.Time) bool {
+	if plan == nil {
+		return false
+	}
+	if plan.Stale {
+		return true
+	}
+	if plan.Token != nil && expiresWithinMargin(plan.Token.ExpiresAt, now) {
+		return true
+	}
+	return plan.DownloadURL != nil && expiresWithinMargin(plan.DownloadURL.ExpiresAt, now)
+}
+
+func expiresWithinMargin(expiresAt int64, now time.Time) bool {
+	return expiresAt > 0 && !now.Add(downloadPlanRefreshMargin).Before(time.Unix(expiresAt, 0))
+}
+
+// parseDownloadURLExpireTime parses the server's expireTime. Timestamps
+// without a zone are in local time; numeric values are unix seconds or
+// milliseconds. An empty value yields zero.
+func parseDownloadURLExpireTime(raw string) (int64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	if value, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return unixTime(value).Unix(), nil
+	}
+	for _, layout := range downloadURLExpireTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return parsed.Unix(), nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported expire time format: %s", raw)
+}
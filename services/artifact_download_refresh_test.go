@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+func TestExecuteDownloadPlanRefreshesStalePlan(t *testing.T) {
+	urlRequests := 0
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":12,"projectName":"proj-a","fullPath":"repo/a.zip"}}`))
+		case "/aiplorer/jfrog/token":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"access_token":"token","expires_in":3600}}`))
+		case "/aiplorer/artifact/download-url":
+			urlRequests++
+			expireTime := time.Now().Add(-time.Minute).Format("2006-01-02 15:04:05")
+			if urlRequests > 1 {
+				expireTime = time.Now().Add(time.Hour).Format("2006-01-02 15:04:05")
+			}
+			_, _ = w.Write([]byte(`{"code":0,"data":{"fileName":"a.zip","filePath":"repo/a.zip","downloadUrl":"https://jfrog.example.com/a.zip?v=` +
+				string(rune('0'+urlRequests)) + `","expireTime":"` + expireTime + `"}}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+	attempts := 0
+	service.downloadArtifact = func(token *models.JfrogTokenInfo, filePath, targetDir string) error {
+		attempts++
+		if attempts == 1 {
+			return utils.NewNetworkError("connection reset", nil)
+		}
+		return nil
+	}
+
+	plan, err := service.PrepareDownloadByArtifactID(12, t.TempDir()+"/")
+	if err != nil {
+		t.Fatalf("PrepareDownloadByArtifactID error: %v", err)
+	}
+	if plan.DownloadURL.ExpiresAt == 0 || !IsDownloadPlanStale(plan, time.Now()) {
+		t.Fatalf("expired download url must make the plan stale: %#v", plan.DownloadURL)
+	}
+
+	_, err = service.ExecuteDownloadPlan(plan)
+	var sdkErr *utils.SDKError
+	if !errors.As(err, &sdkErr) || sdkErr.Code != utils.ErrCodeNetworkError {
+		t.Fatalf("expected interrupted download, got %v", err)
+	}
+	if urlRequests != 2 || plan.Stale || plan.DownloadURL.DownloadURL != "https://jfrog.example.com/a.zip?v=2" {
+		t.Fatalf("plan must be refreshed before downloading: requests=%d %#v", urlRequests, plan.DownloadURL)
+	}
+	if _, err := service.ExecuteDownloadPlan(plan); err != nil {
+		t.Fatalf("ExecuteDownloadPlan retry error: %v", err)
+	}
+	if urlRequests != 2 {
+		t.Fatalf("retry must reuse the refreshed url, got %d url requests", urlRequests)
+	}
+}
+
+func TestParseDownloadURLExpireTime(t *testing.T) {
+	local := time.Date(2026, 3, 18, 12, 0, 0, 0, time.Local).Unix()
+	for raw, want := range map[string]int64{
+		"":                      0,
+		"2026-03-18 12:00:00":   local,
+		"2026-03-18T04:00:00Z":  time.Date(2026, 3, 18, 4, 0, 0, 0, time.UTC).Unix(),
+		"1773806400":            1773806400,
+		"1773806400000":         1773806400,
+		" 2026-03-18 12:00:00 ": local,
+	} {
+		got, err := parseDownloadURLExpireTime(raw)
+		if err != nil || got != want {
+			t.Fatalf("parseDownloadURLExpireTime(%q) = %d, %v; want %d", raw, got, err, want)
+		}
+	}
+	if _, err := parseDownloadURLExpireTime("tomorrow"); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+// ExpandVirtualArtifact follows a virtual artifact's dependency edges until
+// it reaches concrete artifacts and returns their full details, in discovery
+// order and without duplicates. Each result can be passed straight to
+// PrepareDownloadByArtifactID. A concrete artifact expands to itself.
+func (s *artifactService) ExpandVirtualArtifact(artifactID uint64, options *models.ArtifactExpandOptions) ([]models.ArtifactInfo, error) {
+	if options == nil {
+		options = &models.ArtifactExpandOptions{}
+	}
+	root, err := s.GetArtifactByID(artifactID)
+	if err != nil {
+		return nil, err
+	}
+	if root.ID == nil {
+		root.ID = &artifactID
+	}
+
+	var concrete []models.ArtifactInfo
+	visited := map[uint64]bool{artifactID: true}
+	queue := []*models.ArtifactInfo{root}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if !isVirtualArtifact(current.IsVirtual) {
+			if !artifactPlatformMatches(current.Platform, options.Platform) {
+				continue
+			}
+			if !artifactAvailable(current) {
+				return nil, utils.NewNotFoundError(fmt.Sprintf("concrete artifact %d expanded from %d has no uploaded file", *current.ID, artifactID), nil)
+			}
+			concrete = append(concrete, *current)
+			continue
+		}
+		for _, dependency := range current.Dependencies {
+			if dependency.ID == nil || visited[*dependency.ID] {
+				continue
+			}
+			// Dependency lists can carry the whole subtree; only follow direct edges.
+			if dependency.ParentID != nil && *dependency.ParentID != 0 && *dependency.ParentID != *current.ID {
+				continue
+			}
+			// Skip concrete children on other platforms without loading them.
+			if !isVirtualArtifact(dependency.IsVirtual) && !artifactPlatformMatches(dependency.Platform, options.Platform) {
+				continue
+			}
+			visited[*dependency.ID] = true
+			detail, err := s.GetArtifactByID(*dependency.ID)
+			if err != nil {
+				return nil, err
+			}
+			if detail.ID == nil {
+				detail.ID = dependency.ID
+			}
+			queue = append(queue, detail)
+		}
+	}
+	if len(concrete) == 0 {
+		return nil, utils.NewNotFoundError(fmt.Sprintf("no concrete artifacts found for artifact %d", artifactID), nil)
+	}
+	return concrete, nil
+}
+
+func isVirtualArtifact(isVirtual *bool) bool {
+	return isVirtual != nil && *isVirtual
+}
+
+func artifactPlatformMatches(platform, want *string) bool {
+	return want == nil || valueOrEmpty(platform) == *want
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func TestExpandVirtualArtifact(t *testing.T) {
+	details := map[float64]string{
+		1: `{"id":1,"name":"bundle","isVirtual":true,"dependencies":[` +
+			`{"id":2,"isVirtual":true,"parentId":1},{"id":3,"platform":"x9","parentId":1},{"id":4,"platform":"j5","parentId":1},` +
+			`{"id":5,"platform":"x9","parentId":2}]}`,
+		2: `{"id":2,"name":"sub-bundle","isVirtual":true,"dependencies":[{"id":5,"platform":"x9"},{"id":3,"platform":"x9"}]}`,
+		3: `{"id":3,"name":"app","platform":"x9","projectName":"demo","fullPath":"repo/app.tar.gz","fileHash":"` + helloMD5 + `"}`,
+		4: `{"id":4,"name":"app","platform":"j5","projectName":"demo","fullPath":"repo/app-j5.tar.gz","fileHash":"` + helloMD5 + `"}`,
+		5: `{"id":5,"name":"msg","platform":"x9","projectName":"demo","fullPath":"repo/msg.tar.gz","fileHash":"` + helloMD5 + `"}`,
+	}
+	var loaded []float64
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/aiplorer/artifact" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		id := decodeBody(t, r)["id"].(float64)
+		loaded = append(loaded, id)
+		_, _ = w.Write([]byte(`{"code":0,"data":` + details[id] + `}`))
+	})
+
+	platform := "x9"
+	artifacts, err := service.ExpandVirtualArtifact(1, &models.ArtifactExpandOptions{Platform: &platform})
+	if err != nil {
+		t.Fatalf("ExpandVirtualArtifact error: %v", err)
+	}
+	if len(artifacts) != 2 || *artifacts[0].ID != 3 || *artifacts[1].ID != 5 {
+		t.Fatalf("unexpected expansion: %#v", artifacts)
+	}
+	for _, id := range loaded {
+		if id == 4 {
+			t.Fatalf("artifact on another platform must not be loaded: %v", loaded)
+		}
+	}
+
+	artifacts, err = service.ExpandVirtualArtifact(4, nil)
+	if err != nil || len(artifacts) != 1 || *artifacts[0].ID != 4 {
+		t.Fatalf("concrete artifact must expand to itself: %#v %v", artifacts, err)
+	}
+}
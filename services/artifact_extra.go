@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+// DecodeArtifactExtra converts an artifact's Extra metadata into a
+// caller-supplied struct and runs its Validate method when one is defined.
+// Empty Extra decodes to the zero value.
+func DecodeArtifactExtra[T any](artifact *models.ArtifactInfo) (*T, error) {
+	if artifact == nil {
+		return nil, utils.NewInvalidInputError("artifact is nil", nil)
+	}
+	extra, err := artifactExtraMap(artifact)
+	if err != nil {
+		return nil, err
+	}
+	var typed T
+	if err := remarshalJSON(extra, &typed); err != nil {
+		return nil, utils.NewAPIError("failed to decode artifact extra", err)
+	}
+	if err := validateTyped(&typed); err != nil {
+		return nil, utils.NewInvalidInputError("artifact extra failed validation", err)
+	}
+	return &typed, nil
+}
+
+// GetArtifactExtraAs loads an artifact and decodes its Extra metadata into T.
+func GetArtifactExtraAs[T any](service ArtifactService, artifactID uint64) (*T, error) {
+	artifact, err := service.GetArtifactByID(artifactID)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeArtifactExtra[T](artifact)
+}
+
+// MergeArtifactExtraFrom merges the JSON form of a typed value into an
+// artifact's Extra metadata with MergeArtifactExtra.
+func MergeArtifactExtraFrom[T any](service ArtifactService, artifactID uint64, value *T) (map[string]any, error) {
+	if value == nil {
+		return nil, utils.NewInvalidInputError("artifact extra is nil", nil)
+	}
+	if err := validateTyped(value); err != nil {
+		return nil, utils.NewInvalidInputError("artifact extra failed validation", err)
+	}
+	var patch map[string]any
+	if err := remarshalJSON(value, &patch); err != nil {
+		return nil, utils.NewInvalidInputError("artifact extra must encode to a JSON object", err)
+	}
+	return service.MergeArtifactExtra(artifactID, patch)
+}
+
+// MergeArtifactExtra applies patch to an artifact's Extra metadata as an
+// RFC 7396 merge patch, so keys it does not mention are preserved and null
+// values remove keys. The update is conditional on the UpdatedAt it was
+// merged from and retried on conflict, as in PatchArtifactTags.
+func (s *artifactService) MergeArtifactExtra(artifactID uint64, patch map[string]any) (map[string]any, error) {
+	if len(patch) == 0 {
+		return nil, utils.NewInvalidInputError("artifact extra patch is empty", nil)
+	}
+	normalized := cloneJSONValue(patch)
+	var merged map[string]any
+	_, _, err := s.updateArtifactIfUnchanged(artifactID, defaultTagPatchMaxRetries, func(artifact *models.ArtifactInfo) (*models.ArtifactInfo, error) {
+		current, err := artifactExtraMap(artifact)
+		if err != nil {
+			return nil, err
+		}
+		merged, _ = applyMergePatch(current, cloneJSONValue(normalized)).(map[string]any)
+		return &models.ArtifactInfo{Extra: stringPtr(mustJSON(merged))}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// FindArtifactDependencies returns the dependencies of root that satisfy the
+// query, in the order root lists them. Name, type, platform and module path
+// are checked on the dependency list before any detail is loaded. Virtual
+// dependencies are skipped unless ExpandVirtual is set, in which case the
+// query is applied to the concrete artifacts they expand to.
+func (s *artifactService) FindArtifactDependencies(root *models.ArtifactInfo, query *models.ArtifactDependencyQuery) ([]models.ArtifactInfo, error) {
+	if root == nil {
+		return nil, utils.NewInvalidInputError("root artifact is nil", nil)
+	}
+	if query == nil {
+		query = &models.ArtifactDependencyQuery{}
+	}
+
+	var matched []models.ArtifactInfo
+	seen := map[uint64]bool{}
+	consider := func(detail *models.ArtifactInfo) error {
+		if detail.ID == nil || seen[*detail.ID] {
+			return nil
+		}
+		seen[*detail.ID] = true
+		if !matchDependencyFields(query, detail.Name, detail.Type, detail.Platform, detail.ModulePath) {
+			return nil
+		}
+		ok, err := matchDependencyExtra(query, detail)
+		if err != nil || !ok {
+			return err
+		}
+		matched = append(matched, *detail)
+		return nil
+	}
+
+	for _, dependency := range root.Dependencies {
+		if dependency.ID == nil || seen[*dependency.ID] {
+			continue
+		}
+		if isVirtualArtifact(dependency.IsVirtual) {
+			if !query.ExpandVirtual {
+				continue
+			}
+			expanded, err := s.ExpandVirtualArtifact(*dependency.ID, &models.ArtifactExpandOptions{Platform: query.Platform})
+			if err != nil {
+				var sdkErr *utils.SDKError
+				if errors.As(err, &sdkErr) && sdkErr.Code == utils.ErrCodeNotFound {
+					continue
+				}
+				return nil, err
+			}
+			for i := range expanded {
+				if err := consider(&expanded[i]); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if !matchDependencyFields(query, dependency.Name, dependency.Type, dependency.Platform, dependency.ModulePath) {
+			continue
+		}
+		detail, err := s.GetArtifactByID(*dependency.ID)
+		if err != nil {
+			return nil, err
+		}
+		if detail.ID == nil {
+			detail.ID = dependency.ID
+		}
+		if err := consider(detail); err != nil {
+			return nil, err
+		}
+	}
+	return matched, nil
+}
+
+// FindArtifactDependency returns the first dependency of root that satisfies
+// the query.
+func (s *artifactService) FindArtifactDependency(root *models.ArtifactInfo, query *models.ArtifactDependencyQuery) (*models.ArtifactInfo, error) {
+	matched, err := s.FindArtifactDependencies(root, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(matched) == 0 {
+		return nil, utils.NewNotFoundError(fmt.Sprintf("no dependency of artifact %s matches the query", valueOrEmpty(root.Name)), nil)
+	}
+	return &matched[0], nil
+}
+
+func matchDependencyFields(query *models.ArtifactDependencyQuery, name, artifactType, platform, modulePath *string) bool {
+	return matchTrimmed(name, query.Name) &&
+		matchTrimmed(artifactType, query.Type) &&
+		matchTrimmed(platform, query.Platform) &&
+		matchTrimmed(modulePath, query.ModulePath)
+}
+
+func matchTrimmed(value, want *string) bool {
+	return want == nil || strings.TrimSpace(valueOrEmpty(value)) == strings.TrimSpace(*want)
+}
+
+func matchDependencyExtra(query *models.ArtifactDependencyQuery, artifact *models.ArtifactInfo) (bool, error) {
+	if len(query.Extra) > 0 {
+		extra, err := artifactExtraMap(artifact)
+		if err != nil {
+			return false, err
+		}
+		want, _ := cloneJSONValue(query.Extra).(map[string]any)
+		for key, value := range want {
+			if !matchExtraValue(extra[key], value) {
+				return false, nil
+			}
+		}
+	}
+	return query.Match == nil || query.Match(artifact), nil
+}
+
+// matchExtraValue compares an Extra value with the wanted one, ignoring
+// surrounding whitespace in strings as the other dependency fields do.
+func matchExtraValue(value, want any) bool {
+	if text, ok := value.(string); ok {
+		if wantText, ok := want.(string); ok {
+			return strings.TrimSpace(text) == strings.TrimSpace(wantText)
+		}
+	}
+	return reflect.DeepEqual(value, want)
+}
+
+func artifactExtraMap(artifact *models.ArtifactInfo) (map[string]any, error) {
+	raw := valueOrEmpty(artifact.Extra)
+	if strings.TrimSpace(raw) == "" {
+		return map[string]any{}, nil
+	}
+	extra, err := models.ParseJSON(raw)
+	if err != nil {
+		return nil, utils.NewAPIError("failed to decode artifact extra", err)
+	}
+	return extra, nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+type testMsgExtra struct {
+	ArtifactType string `json:"artifact_type"`
+	ProjectName  string `json:"project_name"`
+	Platform     string `json:"platform,omitempty"`
+}
+
+func TestArtifactExtraAccessorsAndMerge(t *testing.T) {
+	extra := `{"artifact_type":"msg","project_name":"demo","owner":"perception"}`
+	updatedAt := 100
+	racing := false
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeBody(t, r)
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":` + mustJSON(map[string]any{"id": 1, "extra": extra, "updatedAt": updatedAt}) + `}`))
+		case "/aiplorer/artifact/update":
+			if racing {
+				// Another writer lands between the read and this update.
+				racing = false
+				extra = strings.Replace(extra, "{", `{"reviewer":"qa",`, 1)
+				updatedAt++
+			}
+			if payload["expectedUpdatedAt"] != float64(updatedAt) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			extra = payload["extra"].(string)
+			updatedAt++
+			_, _ = w.Write([]byte(`{"code":0,"msg":"updated"}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	typed, err := GetArtifactExtraAs[testMsgExtra](service, 1)
+	if err != nil || typed.ArtifactType != "msg" || typed.ProjectName != "demo" {
+		t.Fatalf("unexpected typed extra: %#v %v", typed, err)
+	}
+
+	if _, err := MergeArtifactExtraFrom(service, 1, &testMsgExtra{ArtifactType: "msg", ProjectName: "demo", Platform: "x9"}); err != nil {
+		t.Fatalf("MergeArtifactExtraFrom error: %v", err)
+	}
+	racing = true
+	if _, err := service.MergeArtifactExtra(1, map[string]any{"artifact_type": nil}); err != nil {
+		t.Fatalf("MergeArtifactExtra error: %v", err)
+	}
+	var written map[string]any
+	if err := json.Unmarshal([]byte(extra), &written); err != nil {
+		t.Fatalf("decode extra: %v", err)
+	}
+	if written["owner"] != "perception" || written["platform"] != "x9" {
+		t.Fatalf("merge must preserve unrelated keys: %#v", written)
+	}
+	if written["reviewer"] != "qa" {
+		t.Fatalf("a rejected merge must be redone on the latest extra: %#v", written)
+	}
+	if _, ok := written["artifact_type"]; ok {
+		t.Fatalf("null must remove the key: %#v", written)
+	}
+}
+
+func TestFindArtifactDependencies(t *testing.T) {
+	details := map[float64]string{
+		2: `{"id":2,"name":"demo-msg","type":"app","platform":"x9","extra":"{\"artifact_type\":\"proto\"}"}`,
+		3: `{"id":3,"name":"demo-msg","type":"app","platform":"x9","extra":"{\"artifact_type\":\"msg\",\"project_name\":\" demo \"}"}`,
+		4: `{"id":4,"name":"bundle","isVirtual":true,"dependencies":[{"id":5,"platform":"x9"}]}`,
+		5: `{"id":5,"name":"demo-msg","type":"app","platform":"x9","fullPath":"repo/msg.tar.gz","fileHash":"` + helloMD5 + `",` +
+			`"extra":"{\"artifact_type\":\"msg\",\"project_name\":\"demo\"}"}`,
+	}
+	var loaded []float64
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		id := decodeBody(t, r)["id"].(float64)
+		loaded = append(loaded, id)
+		_, _ = w.Write([]byte(`{"code":0,"data":` + details[id] + `}`))
+	})
+	var root models.ArtifactInfo
+	if err := json.Unmarshal([]byte(`{"id":10,"name":"demo","dependencies":[`+
+		`{"id":1,"name":"demo-proto","type":"app"},`+
+		`{"id":2,"name":"demo-msg","type":"app","platform":"x9"},`+
+		`{"id":3,"name":"demo-msg","type":"app","platform":"x9"},`+
+		`{"id":4,"isVirtual":true}]}`), &root); err != nil {
+		t.Fatalf("decode root: %v", err)
+	}
+
+	platform := "x9"
+	query := &models.ArtifactDependencyQuery{
+		Name:     stringPtr("demo-msg"),
+		Platform: &platform,
+		Extra:    map[string]any{"artifact_type": "msg", "project_name": "demo"},
+	}
+	matched, err := service.FindArtifactDependencies(&root, query)
+	if err != nil || len(matched) != 1 || *matched[0].ID != 3 {
+		t.Fatalf("padded extra strings must match; unexpected matches: %#v %v", matched, err)
+	}
+	for _, id := range loaded {
+		if id == 1 {
+			t.Fatalf("dependency filtered by name must not be loaded: %v", loaded)
+		}
+	}
+
+	query.ExpandVirtual = true
+	query.Match = func(artifact *models.ArtifactInfo) bool { return valueOrEmpty(artifact.FullPath) != "" }
+	child, err := service.FindArtifactDependency(&root, query)
+	if err != nil || *child.ID != 5 {
+		t.Fatalf("expected expanded virtual child, got %#v %v", child, err)
+	}
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	jfrogServices "github.com/jfrog/jfrog-client-go/artifactory/services"
+	jfrogUtils "github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+var (
+	importPlaceholderPattern = regexp.MustCompile(`\{(\w+|\*)\}`)
+	importPlaceholders       = map[string]bool{
+		"repo": true, "name": true, "type": true, "platform": true,
+		"version": true, "commit": true, "module": true, "file": true,
+	}
+	importArchiveExtensions = []string{".tar.gz", ".tar.bz2", ".tar.xz", ".tar.zst"}
+)
+
+// ListJfrogFiles lists the JFrog files matching pattern with the project's
+// token.
+func (s *artifactService) ListJfrogFiles(projectName, pattern string) ([]models.JfrogFileInfo, error) {
+	if strings.TrimSpace(projectName) == "" || strings.TrimSpace(pattern) == "" {
+		return nil, utils.NewInvalidInputError("project name and pattern are required to list jfrog files", nil)
+	}
+	token, err := s.GetJfrogToken(projectName)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.searchJfrog(token, pattern)
+	if err != nil && isJfrogAuthError(err) {
+		utils.Warn("JFrog rejected the token for project %s, refreshing: %v", projectName, err)
+		s.jfrogTokens.invalidate(projectName, token)
+		if token, err = s.GetJfrogToken(projectName); err != nil {
+			return nil, err
+		}
+		files, err = s.searchJfrog(token, pattern)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].FullPath < files[j].FullPath })
+	return files, nil
+}
+
+// ImportJfrogArtifacts registers the JFrog files of a project that no
+// artifact, in any project, refers to by FullPath or FileHash. Files sharing
+// a path or hash with one registered earlier in the run are reported as
+// tracked. A PathConvention is required. With DryRun the report lists what
+// would be registered without creating anything.
+func (s *artifactService) ImportJfrogArtifacts(req *models.ArtifactImportReq) (*models.ArtifactImportReport, error) {
+	if req == nil {
+		return nil, utils.NewInvalidInputError("artifact import request is nil", nil)
+	}
+	if strings.TrimSpace(req.PathConvention) == "" {
+		return nil, utils.NewInvalidInputError("path convention is required to import jfrog artifacts", nil)
+	}
+	convention, err := compileImportPathConvention(req.PathConvention)
+	if err != nil {
+		return nil, utils.NewInvalidInputError(fmt.Sprintf("invalid path convention: %s", req.PathConvention), err)
+	}
+	files, err := s.ListJfrogFiles(req.ProjectName, req.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := map[string]*models.ArtifactInfo{}
+	byHash := map[string]*models.ArtifactInfo{}
+	// Files may already be registered under another project or none, so
+	// every artifact is reconciled, not only the project's.
+	if err := s.forEachArtifact(&models.ArtifactListReq{}, func(item models.ArtifactInfo) (bool, error) {
+		artifact := item
+		indexImportArtifact(&artifact, byPath, byHash)
+		return true, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	report := &models.ArtifactImportReport{
+		DryRun:    req.DryRun,
+		Scanned:   len(files),
+		Decisions: make([]models.ArtifactImportDecision, 0, len(files)),
+	}
+	for _, file := range files {
+		decision := models.ArtifactImportDecision{File: file}
+		if existing := trackedJfrogFile(file, byPath, byHash); existing != nil {
+			decision.Action = models.ArtifactImportTracked
+			decision.Artifact = existing
+			report.Decisions = append(report.Decisions, decision)
+			continue
+		}
+		artifact := inferImportedArtifact(file, convention, req)
+		switch {
+		case artifact == nil:
+			decision.Action = models.ArtifactImportUnmatched
+		case req.DryRun:
+			decision.Action = models.ArtifactImportWouldImport
+			decision.Artifact = artifact
+			indexImportArtifact(artifact, byPath, byHash)
+		default:
+			decision.Artifact = artifact
+			if _, err := s.CreateArtifact(artifact); err != nil {
+				decision.Action = models.ArtifactImportFailed
+				decision.Error = err.Error()
+			} else {
+				decision.Action = models.ArtifactImportImported
+				indexImportArtifact(artifact, byPath, byHash)
+			}
+		}
+		report.Decisions = append(report.Decisions, decision)
+	}
+
+	for _, decision := range report.Decisions {
+		switch decision.Action {
+		case models.ArtifactImportTracked:
+			report.Tracked++
+		case models.ArtifactImportUnmatched:
+			report.Unmatched++
+		case models.ArtifactImportFailed:
+			report.Failed++
+		case models.ArtifactImportWouldImport:
+			report.WouldImport++
+		default:
+			report.Imported++
+		}
+	}
+	return report, nil
+}
+
+// indexImportArtifact records an artifact so later files with the same path
+// or hash are reported as tracked.
+func indexImportArtifact(artifact *models.ArtifactInfo, byPath, byHash map[string]*models.ArtifactInfo) {
+	if fullPath := normalizeJfrogPath(valueOrEmpty(artifact.FullPath)); fullPath != "" {
+		byPath[fullPath] = artifact
+	}
+	if fileHash := strings.ToLower(strings.TrimSpace(valueOrEmpty(artifact.FileHash))); fileHash != "" {
+		byHash[fileHash] = artifact
+	}
+}
+
+func trackedJfrogFile(file models.JfrogFileInfo, byPath, byHash map[string]*models.ArtifactInfo) *models.ArtifactInfo {
+	if existing := byPath[normalizeJfrogPath(file.FullPath)]; existing != nil {
+		return existing
+	}
+	for _, hash := range []string{file.MD5, file.SHA1, file.SHA256} {
+		if existing := byHash[strings.ToLower(hash)]; hash != "" && existing != nil {
+			return existing
+		}
+	}
+	return nil
+}
+
+// compileImportPathConvention turns a path convention into a regular
+// expression with one named group per placeholder.
+func compileImportPathConvention(convention string) (*regexp.Regexp, error) {
+	if strings.TrimSpace(convention) == "" {
+		return nil, nil
+	}
+	var expr strings.Builder
+	expr.WriteString("^")
+	seen := map[string]bool{}
+	last := 0
+	for _, match := range importPlaceholderPattern.FindAllStringSubmatchIndex(convention, -1) {
+		expr.WriteString(regexp.QuoteMeta(convention[last:match[0]]))
+		last = match[1]
+		name := convention[match[2]:match[3]]
+		switch {
+		case name == "*":
+			expr.WriteString(`[^/]+?`)
+		case !importPlaceholders[name]:
+			return nil, fmt.Errorf("unknown placeholder {%s}", name)
+		case seen[name]:
+			return nil, fmt.Errorf("placeholder {%s} is used twice", name)
+		case name == "file":
+			seen[name] = true
+			expr.WriteString(`(?P<file>[^/]+)`)
+		default:
+			seen[name] = true
+			expr.WriteString(`(?P<` + name + `>[^/]+?)`)
+		}
+	}
+	expr.WriteString(regexp.QuoteMeta(convention[last:]))
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// inferImportedArtifact builds the artifact to register for a JFrog file,
+// or nil when the path does not follow the convention.
+func inferImportedArtifact(file models.JfrogFileInfo, convention *regexp.Regexp, req *models.ArtifactImportReq) *models.ArtifactInfo {
+	fields := map[string]string{}
+	if convention != nil {
+		match := convention.FindStringSubmatch(normalizeJfrogPath(file.FullPath))
+		if match == nil {
+			return nil
+		}
+		for i, name := range convention.SubexpNames() {
+			if name != "" {
+				fields[name] = match[i]
+			}
+		}
+	}
+	name := fields["name"]
+	if name == "" {
+		name = importFileStem(file.Name)
+	}
+	if name == "" {
+		return nil
+	}
+
+	artifact := &models.ArtifactInfo{
+		Name:        stringPtr(name),
+		ProjectName: stringPtr(req.ProjectName),
+		FullPath:    stringPtr(normalizeJfrogPath(file.FullPath)),
+		Extra:       stringPtr(`{"import_source":"jfrog"}`),
+	}
+	if fileHash := firstNonEmpty(file.SHA256, file.SHA1, file.MD5); fileHash != "" {
+		artifact.FileHash = stringPtr(fileHash)
+	}
+	if artifactType := firstNonEmpty(fields["type"], req.Type); artifactType != "" {
+		artifact.Type = stringPtr(artifactType)
+	}
+	for field, target := range map[string]**string{
+		"platform": &artifact.Platform,
+		"version":  &artifact.SemanticVersion,
+		"commit":   &artifact.CommitHash,
+		"module":   &artifact.ModulePath,
+	} {
+		if value := fields[field]; value != "" {
+			*target = stringPtr(value)
+		}
+	}
+	if created, err := time.Parse(time.RFC3339, file.Created); err == nil {
+		buildDate := created.Unix()
+		artifact.BuildDate = &buildDate
+	}
+	return artifact
+}
+
+func importFileStem(fileName string) string {
+	lower := strings.ToLower(fileName)
+	for _, extension := range importArchiveExtensions {
+		if strings.HasSuffix(lower, extension) {
+			return fileName[:len(fileName)-len(extension)]
+		}
+	}
+	return strings.TrimSuffix(fileName, path.Ext(fileName))
+}
+
+func normalizeJfrogPath(fullPath string) string {
+	return strings.Trim(path.Clean("/"+strings.TrimSpace(fullPath)), "/")
+}
+
+func searchWithJFrog(token *models.JfrogTokenInfo, pattern string) ([]models.JfrogFileInfo, error) {
+	manager, err := newJfrogManager(token)
+	if err != nil {
+		return nil, err
+	}
+	params := jfrogServices.NewSearchParams()
+	params.Pattern = pattern
+	params.Recursive = true
+	reader, err := manager.SearchFiles(params)
+	if err != nil {
+		return nil, jfrogRequestError(err, "failed to search jfrog files")
+	}
+	defer reader.Close()
+
+	var files []models.JfrogFileInfo
+	for item := new(jfrogUtils.ResultItem); reader.NextRecord(item) == nil; item = new(jfrogUtils.ResultItem) {
+		if item.Type == "folder" {
+			continue
+		}
+		files = append(files, models.JfrogFileInfo{
+			Repo:     item.Repo,
+			Path:     item.Path,
+			Name:     item.Name,
+			FullPath: normalizeJfrogPath(path.Join(item.Repo, item.Path, item.Name)),
+			Size:     item.Size,
+			MD5:      item.Actual_Md5,
+			SHA1:     item.Actual_Sha1,
+			SHA256:   item.Sha256,
+			Created:  item.Created,
+		})
+	}
+	if err := reader.GetError(); err != nil {
+		return nil, utils.NewInternalError("failed to read jfrog search results", err)
+	}
+	return files, nil
+}
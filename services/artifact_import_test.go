@@ -0,0 +1,115 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func TestImportJfrogArtifactsAgainstStubServer(t *testing.T) {
+	var aqlQueries []string
+	var created []map[string]any
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/jfrog/token":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"access_token":"jfrog-token","url":"http://` + r.Host + `"}}`))
+		case "/artifactory/api/system/version":
+			_, _ = w.Write([]byte(`{"version":"7.90.0","revision":"79000900"}`))
+		case "/artifactory/api/search/aql":
+			if r.Header.Get("Authorization") != "Bearer jfrog-token" {
+				t.Fatalf("jfrog search must use the project token, got %q", r.Header.Get("Authorization"))
+			}
+			body, _ := io.ReadAll(r.Body)
+			aqlQueries = append(aqlQueries, string(body))
+			_, _ = w.Write([]byte(`{"results":[` +
+				`{"repo":"generic-local","path":"vision/x9/1.2.0","name":"vision.tar.gz","type":"file","size":5,"actual_md5":"` + helloMD5 + `","sha256":"` + helloSHA256 + `","created":"2024-05-01T08:00:00.000Z"},` +
+				`{"repo":"generic-local","path":"vision/x9/1.3.0","name":"vision.tar.gz","type":"file","size":5,"actual_md5":"` + helloMD5 + `","sha256":"` + helloSHA256 + `"},` +
+				`{"repo":"generic-local","path":"vision/x9/1.1.0","name":"vision.tar.gz","type":"file","actual_md5":"11111111111111111111111111111111"},` +
+				`{"repo":"generic-local","path":"vision/j5/1.0.0","name":"vision.tar.gz","type":"file","actual_md5":"22222222222222222222222222222222"},` +
+				`{"repo":"generic-local","path":"vision","name":"README.md","type":"file","actual_md5":"33333333333333333333333333333333"}` +
+				`],"range":{"start_pos":0,"end_pos":5,"total":5}}`))
+		case "/aiplorer/artifact/list":
+			if payload := decodeBody(t, r); payload["projectName"] != nil {
+				t.Fatalf("existing artifacts of every project must be listed: %#v", payload)
+			}
+			_, _ = w.Write([]byte(`{"code":0,"data":{"total":2,"data":[` +
+				`{"id":1,"fullPath":"/generic-local/vision/x9/1.1.0/vision.tar.gz"},` +
+				`{"id":2,"projectName":"legacy","fullPath":"moved/vision.tar.gz","fileHash":"22222222222222222222222222222222"}]}}`))
+		case "/aiplorer/artifact/create":
+			created = append(created, decodeBody(t, r))
+			_, _ = w.Write([]byte(`{"code":0,"msg":"ok"}`))
+		default:
+			t.Fatalf("unexpected path: %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	req := &models.ArtifactImportReq{
+		ProjectName:    "vision",
+		Pattern:        "generic-local/vision/*",
+		PathConvention: "{repo}/{name}/{platform}/{version}/{file}",
+		Type:           "pkg",
+		DryRun:         true,
+	}
+	report, err := service.ImportJfrogArtifacts(req)
+	if err != nil {
+		t.Fatalf("ImportJfrogArtifacts dry run error: %v", err)
+	}
+	if len(aqlQueries) != 1 || !strings.Contains(aqlQueries[0], `"generic-local"`) {
+		t.Fatalf("unexpected aql queries: %v", aqlQueries)
+	}
+	if report.Scanned != 5 || report.Tracked != 3 || report.WouldImport != 1 || report.Imported != 0 || report.Unmatched != 1 || len(created) != 0 {
+		t.Fatalf("unexpected dry run report: %#v", report)
+	}
+	actions := map[string]string{}
+	for _, decision := range report.Decisions {
+		actions[decision.File.FullPath] = decision.Action
+	}
+	if actions["generic-local/vision/x9/1.2.0/vision.tar.gz"] != models.ArtifactImportWouldImport ||
+		actions["generic-local/vision/x9/1.3.0/vision.tar.gz"] != models.ArtifactImportTracked ||
+		actions["generic-local/vision/README.md"] != models.ArtifactImportUnmatched {
+		t.Fatalf("unexpected decisions: %#v", actions)
+	}
+
+	req.PathConvention = ""
+	if _, err := service.ImportJfrogArtifacts(req); err == nil {
+		t.Fatal("dry runs without a path convention must be rejected")
+	}
+	req.PathConvention = "{repo}/{name}/{platform}/{version}/{file}"
+
+	req.DryRun = false
+	report, err = service.ImportJfrogArtifacts(req)
+	if err != nil {
+		t.Fatalf("ImportJfrogArtifacts error: %v", err)
+	}
+	if report.Imported != 1 || report.WouldImport != 0 || report.Tracked != 3 || len(created) != 1 {
+		t.Fatalf("unexpected import report: %#v (created %v)", report, created)
+	}
+	artifact := created[0]
+	if artifact["name"] != "vision" || artifact["platform"] != "x9" || artifact["semanticVersion"] != "1.2.0" ||
+		artifact["type"] != "pkg" || artifact["projectName"] != "vision" || artifact["fileHash"] != helloSHA256 ||
+		artifact["fullPath"] != "generic-local/vision/x9/1.2.0/vision.tar.gz" || artifact["buildDate"] != float64(1714550400) {
+		t.Fatalf("unexpected registered artifact: %#v", artifact)
+	}
+}
+
+func TestCompileImportPathConvention(t *testing.T) {
+	convention, err := compileImportPathConvention("{repo}/{*}/{name}-{version}.tar.gz")
+	if err != nil {
+		t.Fatalf("compileImportPathConvention error: %v", err)
+	}
+	artifact := inferImportedArtifact(models.JfrogFileInfo{FullPath: "repo/any/vision-1.2.0.tar.gz", Name: "vision-1.2.0.tar.gz"}, convention, &models.ArtifactImportReq{})
+	if artifact == nil || *artifact.Name != "vision" || *artifact.SemanticVersion != "1.2.0" {
+		t.Fatalf("unexpected inferred artifact: %#v", artifact)
+	}
+	for _, invalid := range []string{"{repo}/{unknown}", "{name}/{name}"} {
+		if _, err := compileImportPathConvention(invalid); err == nil {
+			t.Fatalf("convention %q must be rejected", invalid)
+		}
+	}
+	if artifact := inferImportedArtifact(models.JfrogFileInfo{FullPath: "repo/tool.zip", Name: "tool.zip"}, nil, &models.ArtifactImportReq{}); artifact == nil || *artifact.Name != "tool" {
+		t.Fatalf("files without a convention must be named after the file: %#v", artifact)
+	}
+}
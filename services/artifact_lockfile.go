@@ -0,0 +1,224 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+// DefaultArtifactLockfileName is the conventional lockfile name.
+const DefaultArtifactLockfileName = "intranet.lock"
+
+// LoadArtifactLockfile reads a lockfile from disk.
+func LoadArtifactLockfile(path string) (*models.ArtifactLockfile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utils.NewInternalError("failed to read artifact lockfile", err)
+	}
+	var lock models.ArtifactLockfile
+	if err := json.Unmarshal(raw, &lock); err != nil {
+		return nil, utils.NewInvalidInputError(fmt.Sprintf("invalid artifact lockfile: %s", path), err)
+	}
+	if lock.Version > models.ArtifactLockfileVersion {
+		return nil, utils.NewInvalidInputError(fmt.Sprintf("unsupported artifact lockfile version: %d", lock.Version), nil)
+	}
+	return &lock, nil
+}
+
+// SaveArtifactLockfile writes a lockfile to disk with stable formatting.
+func SaveArtifactLockfile(path string, lock *models.ArtifactLockfile) error {
+	if lock == nil {
+		return utils.NewInvalidInputError("artifact lockfile is nil", nil)
+	}
+	buf, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return utils.NewInternalError("failed to encode artifact lockfile", err)
+	}
+	if err := os.WriteFile(path, append(buf, '\n'), 0o644); err != nil {
+		return utils.NewInternalError("failed to write artifact lockfile", err)
+	}
+	return nil
+}
+
+func (s *artifactService) GenerateArtifactLockfile(reqs []models.ArtifactLockRequest) (*models.ArtifactLockfile, error) {
+	lock := &models.ArtifactLockfile{
+		Version:     models.ArtifactLockfileVersion,
+		GeneratedAt: time.Now().Unix(),
+		Entries:     make([]models.ArtifactLockEntry, 0, len(reqs)),
+	}
+	seen := map[string]uint64{}
+	for _, req := range reqs {
+		artifact, err := s.resolveArtifactLockRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		if artifact.ID == nil {
+			return nil, utils.NewAPIError("artifact id is empty", nil)
+		}
+		if artifact.FileHash == nil || *artifact.FileHash == "" {
+			return nil, utils.NewAPIError(fmt.Sprintf("artifact file hash is empty for artifact id: %d", *artifact.ID), nil)
+		}
+		downloadURL, err := s.GetArtifactDownloadURL(*artifact.ID, "artifact")
+		if err != nil {
+			return nil, err
+		}
+		entry := models.ArtifactLockEntry{
+			ID:              *artifact.ID,
+			Name:            valueOrEmpty(artifact.Name),
+			Type:            valueOrEmpty(artifact.Type),
+			CommitHash:      valueOrEmpty(artifact.CommitHash),
+			Platform:        valueOrEmpty(artifact.Platform),
+			ProjectName:     valueOrEmpty(artifact.ProjectName),
+			SemanticVersion: valueOrEmpty(artifact.SemanticVersion),
+			FullPath:        valueOrEmpty(artifact.FullPath),
+			FileHash:        *artifact.FileHash,
+			TargetPath:      filepath.ToSlash(resolveDownloadTarget(req.Destination, downloadURL.FileName)),
+		}
+		if previous, ok := seen[entry.TargetPath]; ok && previous != entry.ID {
+			return nil, utils.NewInvalidInputError(fmt.Sprintf("artifacts %d and %d share target path %s", previous, entry.ID, entry.TargetPath), nil)
+		}
+		if _, ok := seen[entry.TargetPath]; ok {
+			continue
+		}
+		seen[entry.TargetPath] = entry.ID
+		lock.Entries = append(lock.Entries, entry)
+	}
+	sort.SliceStable(lock.Entries, func(i, j int) bool {
+		return lock.Entries[i].TargetPath < lock.Entries[j].TargetPath
+	})
+	return lock, nil
+}
+
+func (s *artifactService) resolveArtifactLockRequest(req models.ArtifactLockRequest) (*models.ArtifactInfo, error) {
+	switch {
+	case req.ArtifactID != 0:
+		return s.GetArtifactByID(req.ArtifactID)
+	case strings.TrimSpace(req.CommitHash) != "":
+		return s.GetArtifactByCommitHash(req.CommitHash, req.Lookup)
+	case strings.TrimSpace(req.Name) != "" && req.Constraint != "":
+		resolution, err := s.ResolveArtifactVersion(&models.ArtifactVersionResolveReq{
+			Name:       req.Name,
+			Lookup:     req.Lookup,
+			Constraint: req.Constraint,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resolution.Best, nil
+	case strings.TrimSpace(req.Name) != "":
+		return s.GetArtifactByName(req.Name, req.Lookup)
+	default:
+		return nil, utils.NewInvalidInputError("artifact lock request requires an artifact id, commit hash or name", nil)
+	}
+}
+
+// VerifyArtifactLockfile checks local files against the lockfile without any
+// server lookups. Relative target paths are resolved against baseDir.
+func (s *artifactService) VerifyArtifactLockfile(lock *models.ArtifactLockfile, baseDir string) (*models.ArtifactLockVerifyResult, error) {
+	if lock == nil {
+		return nil, utils.NewInvalidInputError("artifact lockfile is nil", nil)
+	}
+	result := &models.ArtifactLockVerifyResult{OK: true, Entries: make([]models.ArtifactLockEntryStatus, 0, len(lock.Entries))}
+	for _, entry := range lock.Entries {
+		status, err := verifyArtifactLockEntry(entry, baseDir)
+		if err != nil {
+			return nil, err
+		}
+		if status.Status != models.ArtifactLockStatusOK {
+			result.OK = false
+		}
+		result.Entries = append(result.Entries, status)
+	}
+	return result, nil
+}
+
+// SyncArtifactLockfile downloads entries that are missing or whose local hash
+// does not match the lockfile. Entries already in place need no server lookup.
+func (s *artifactService) SyncArtifactLockfile(lock *models.ArtifactLockfile, baseDir string) (*models.ArtifactLockVerifyResult, error) {
+	result, err := s.VerifyArtifactLockfile(lock, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	for i, status := range result.Entries {
+		if status.Status == models.ArtifactLockStatusOK {
+			continue
+		}
+		if err := s.syncArtifactLockEntry(status); err != nil {
+			return result, err
+		}
+		result.Entries[i].Status = models.ArtifactLockStatusDownloaded
+		result.Entries[i].ActualHash = ""
+	}
+	result.OK = true
+	return result, nil
+}
+
+func (s *artifactService) syncArtifactLockEntry(status models.ArtifactLockEntryStatus) error {
+	entry := status.Entry
+	artifact, err := s.GetArtifactByID(entry.ID)
+	if err != nil {
+		return err
+	}
+	if artifact.FileHash != nil && *artifact.FileHash != "" && !strings.EqualFold(*artifact.FileHash, entry.FileHash) {
+		return utils.NewAPIError(fmt.Sprintf("artifact %d changed since it was locked: file hash %s, locked %s", entry.ID, *artifact.FileHash, entry.FileHash), nil)
+	}
+	plan, err := s.prepareDownload(artifact, filepath.Dir(status.Path)+string(os.PathSeparator))
+	if err != nil {
+		return err
+	}
+	plan.Checksum = entry.FileHash
+	if _, err := s.executeDownloadPlan(plan); err != nil {
+		return err
+	}
+	if plan.TargetPath != status.Path {
+		if err := os.Rename(plan.TargetPath, status.Path); err != nil {
+			return utils.NewInternalError("failed to move downloaded artifact to locked target path", err)
+		}
+	}
+	matched, err := verifyFileHash(status.Path, entry.FileHash)
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return utils.NewAPIError(fmt.Sprintf("downloaded artifact %d does not match locked file hash %s", entry.ID, entry.FileHash), nil)
+	}
+	return nil
+}
+
+func verifyArtifactLockEntry(entry models.ArtifactLockEntry, baseDir string) (models.ArtifactLockEntryStatus, error) {
+	path := filepath.FromSlash(entry.TargetPath)
+	if !filepath.IsAbs(path) && baseDir != "" {
+		path = filepath.Join(baseDir, path)
+	}
+	status := models.ArtifactLockEntryStatus{Entry: entry, Path: path}
+	info, err := os.Stat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return status, utils.NewInternalError("failed to stat locked artifact file", err)
+		}
+		status.Status = models.ArtifactLockStatusMissing
+		return status, nil
+	}
+	if info.IsDir() {
+		status.Status = models.ArtifactLockStatusMismatch
+		return status, nil
+	}
+	actual, err := fileHashHex(path, entry.FileHash)
+	if err != nil {
+		return status, err
+	}
+	status.ActualHash = actual
+	if strings.EqualFold(actual, strings.TrimSpace(entry.FileHash)) {
+		status.Status = models.ArtifactLockStatusOK
+	} else {
+		status.Status = models.ArtifactLockStatusMismatch
+	}
+	return status, nil
+}
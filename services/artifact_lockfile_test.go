@@ -0,0 +1,92 @@
+package services
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+const (
+	helloMD5    = "5d41402abc4b2a76b9719d911017c592"
+	helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+)
+
+func TestArtifactLockfileGenerateVerifySync(t *testing.T) {
+	requests := map[string]int{}
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/aiplorer/artifact/by-commit-hash":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":7,"name":"vision","commitHash":"abc","platform":"x9","projectName":"proj","fileHash":"` + helloMD5 + `"}}`))
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":7,"name":"vision","commitHash":"abc","platform":"x9","projectName":"proj","fileHash":"` + helloMD5 + `"}}`))
+		case "/aiplorer/artifact/download-url":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"fileName":"vision.zip","filePath":"repo/vision.zip"}}`))
+		case "/aiplorer/jfrog/token":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"accessToken":"token","url":"http://jfrog"}}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+	downloads := 0
+	service.downloadArtifact = func(token *models.JfrogTokenInfo, filePath, targetDir string) error {
+		downloads++
+		return os.WriteFile(filepath.Join(targetDir, "vision.zip"), []byte("hello"), 0o644)
+	}
+
+	lock, err := service.GenerateArtifactLockfile([]models.ArtifactLockRequest{
+		{CommitHash: "abc", Destination: "deps/vision.bin"},
+	})
+	if err != nil {
+		t.Fatalf("GenerateArtifactLockfile error: %v", err)
+	}
+	if len(lock.Entries) != 1 || lock.Entries[0].ID != 7 || lock.Entries[0].TargetPath != "deps/vision.bin" || lock.Entries[0].FileHash != helloMD5 {
+		t.Fatalf("unexpected lockfile: %#v", lock)
+	}
+
+	baseDir := t.TempDir()
+	lockPath := filepath.Join(baseDir, DefaultArtifactLockfileName)
+	if err := SaveArtifactLockfile(lockPath, lock); err != nil {
+		t.Fatalf("SaveArtifactLockfile error: %v", err)
+	}
+	loaded, err := LoadArtifactLockfile(lockPath)
+	if err != nil {
+		t.Fatalf("LoadArtifactLockfile error: %v", err)
+	}
+
+	verified, err := service.VerifyArtifactLockfile(loaded, baseDir)
+	if err != nil || verified.OK || verified.Entries[0].Status != models.ArtifactLockStatusMissing {
+		t.Fatalf("unexpected verify result: %#v %v", verified, err)
+	}
+
+	synced, err := service.SyncArtifactLockfile(loaded, baseDir)
+	if err != nil {
+		t.Fatalf("SyncArtifactLockfile error: %v", err)
+	}
+	if !synced.OK || synced.Entries[0].Status != models.ArtifactLockStatusDownloaded || downloads != 1 {
+		t.Fatalf("unexpected sync result: %#v downloads=%d", synced, downloads)
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, "deps", "vision.bin")); err != nil {
+		t.Fatalf("expected locked target path to exist: %v", err)
+	}
+
+	requests = map[string]int{}
+	synced, err = service.SyncArtifactLockfile(loaded, baseDir)
+	if err != nil || !synced.OK || synced.Entries[0].Status != models.ArtifactLockStatusOK {
+		t.Fatalf("unexpected second sync result: %#v %v", synced, err)
+	}
+	if len(requests) != 0 || downloads != 1 {
+		t.Fatalf("sync of an up-to-date lockfile must not contact the server: %v", requests)
+	}
+
+	if err := os.WriteFile(filepath.Join(baseDir, "deps", "vision.bin"), []byte("tampered"), 0o644); err != nil {
+		t.Fatalf("tamper file: %v", err)
+	}
+	verified, err = service.VerifyArtifactLockfile(loaded, baseDir)
+	if err != nil || verified.Entries[0].Status != models.ArtifactLockStatusMismatch || verified.Entries[0].ActualHash == "" {
+		t.Fatalf("unexpected verify result after tamper: %#v %v", verified, err)
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+// ArtifactReader streams an artifact from its signed download URL. When the
+// artifact has a FileHash or trusted signing keys are configured, Close
+// verifies the content read to the end and returns a
+// utils.ErrCodeChecksumMismatch or utils.ErrCodeSignatureInvalid error on
+// mismatch. A stream closed before its end is not downloaded further; Close
+// returns a utils.ErrCodeUnverified error instead.
+type ArtifactReader struct {
+	Artifact    *models.ArtifactInfo
+	DownloadURL *models.ArtifactDownloadURLInfo
+	// Signature is the verified signature of the artifact, or nil when no
+	// trusted keys are configured.
+	Signature *models.ArtifactSignature
+	// Size is the content length reported by the server, or -1 if unknown.
+	Size int64
+
+	body         io.ReadCloser
+	hasher       hashWriter
+	checksum     string
+	signedHasher hashWriter
+	complete     bool
+	closed       bool
+}
+
+// Read reads from the download stream, hashing the content on the way.
+func (r *ArtifactReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if n > 0 {
+		if r.hasher != nil {
+			_, _ = r.hasher.Write(p[:n])
+		}
+		if r.signedHasher != nil {
+			_, _ = r.signedHasher.Write(p[:n])
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		r.complete = true
+	}
+	return n, err
+}
+
+// Close closes the stream and verifies the content read.
+func (r *ArtifactReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if err := r.body.Close(); err != nil {
+		return utils.NewNetworkError("failed to close artifact stream", err)
+	}
+	artifactID := uint64Value(r.Artifact.ID)
+	if r.hasher == nil && r.signedHasher == nil {
+		return nil
+	}
+	if !r.complete {
+		return utils.NewUnverifiedError(fmt.Sprintf("artifact %d stream closed before its end, content not verified", artifactID), nil)
+	}
+	if r.hasher != nil {
+		actual := fmt.Sprintf("%x", r.hasher.Sum(nil))
+		if !strings.EqualFold(actual, strings.TrimSpace(r.checksum)) {
+			return utils.NewChecksumError(fmt.Sprintf("streamed artifact %d does not match file hash %s: got %s", artifactID, r.checksum, actual), nil)
+		}
+	}
+	if r.signedHasher != nil {
+		actual := fmt.Sprintf("%x", r.signedHasher.Sum(nil))
+		if !strings.EqualFold(actual, strings.TrimSpace(r.Signature.Digest)) {
+			return utils.NewSignatureError(fmt.Sprintf("streamed artifact %d does not match the signed digest", artifactID), nil)
+		}
+	}
+	return nil
+}
+
+// OpenArtifactByID opens a verified stream of the artifact. Cancelling ctx
+// aborts the transfer.
+func (s *artifactService) OpenArtifactByID(ctx context.Context, artifactID uint64) (*ArtifactReader, error) {
+	artifact, err := s.GetArtifactByID(artifactID)
+	if err != nil {
+		return nil, err
+	}
+	return s.openArtifact(ctx, artifact)
+}
+
+// OpenArtifactByCommitHash opens a verified stream of the artifact built
+// from the commit.
+func (s *artifactService) OpenArtifactByCommitHash(ctx context.Context, commitHash string, lookup *models.ArtifactLookupOptions) (*ArtifactReader, error) {
+	artifact, err := s.GetArtifactByCommitHash(commitHash, lookup)
+	if err != nil {
+		return nil, err
+	}
+	return s.openArtifact(ctx, artifact)
+}
+
+// OpenArtifactByName opens a verified stream of the named artifact.
+func (s *artifactService) OpenArtifactByName(ctx context.Context, name string, lookup *models.ArtifactLookupOptions) (*ArtifactReader, error) {
+	artifact, err := s.GetArtifactByName(name, lookup)
+	if err != nil {
+		return nil, err
+	}
+	return s.openArtifact(ctx, artifact)
+}
+
+func (s *artifactService) openArtifact(ctx context.Context, artifact *models.ArtifactInfo) (*ArtifactReader, error) {
+	if artifact == nil || artifact.ID == nil {
+		return nil, utils.NewAPIError("artifact id is empty", nil)
+	}
+	reader := &ArtifactReader{Artifact: artifact, checksum: valueOrEmpty(artifact.FileHash)}
+	if reader.checksum != "" {
+		hasher, err := newHasher(reader.checksum)
+		if err != nil {
+			return nil, err
+		}
+		reader.hasher = hasher
+	}
+	// With trusted keys configured, unsigned or badly signed artifacts are
+	// refused before any content is fetched.
+	if keys := s.httpClient.ArtifactTrustedKeys(); len(keys) > 0 {
+		signature, err := checkArtifactSignature(artifact, keys)
+		if err != nil {
+			return nil, err
+		}
+		reader.Signature = signature
+		reader.signedHasher = sha256.New()
+	}
+
+	// A signed URL can expire between being issued and being used; fetch a
+	// new one once when the storage rejects it.
+	var lastErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		downloadURL, err := s.GetArtifactDownloadURL(*artifact.ID, "artifact")
+		if err != nil {
+			return nil, err
+		}
+		if downloadURL.DownloadURL == "" {
+			return nil, utils.NewAPIError(fmt.Sprintf("download url is empty for artifact id: %d", *artifact.ID), nil)
+		}
+		resp, err := s.httpClient.GetURLStream(ctx, downloadURL.DownloadURL, nil)
+		if err == nil {
+			reader.DownloadURL = downloadURL
+			reader.Size = resp.ContentLength
+			reader.body = resp.Body
+			return reader, nil
+		}
+		lastErr = err
+		var sdkErr *utils.SDKError
+		if !errors.As(err, &sdkErr) || (sdkErr.Code != utils.ErrCodeUnauthorized && sdkErr.Code != utils.ErrCodeForbidden) {
+			break
+		}
+		utils.Warn("Download url for artifact %d was rejected, requesting a new one: %v", *artifact.ID, err)
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/client"
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+func TestOpenArtifactStreamsAndVerifiesOnClose(t *testing.T) {
+	fileHash := helloMD5
+	var urlRequests, fileRequests int
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":12,"name":"artifact-a","fileHash":"` + fileHash + `"}}`))
+		case "/aiplorer/artifact/download-url":
+			urlRequests++
+			_, _ = w.Write([]byte(`{"code":0,"data":{"downloadUrl":"http://` + r.Host + `/files/artifact.bin?sig=` + mustJSON(urlRequests) + `","fileName":"artifact.bin"}}`))
+		case "/files/artifact.bin":
+			fileRequests++
+			if r.Header.Get("Authorization") != "" {
+				t.Fatal("signed urls must not receive API credentials")
+			}
+			if r.URL.Query().Get("sig") == "1" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte("hello"))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	reader, err := service.OpenArtifactByID(context.Background(), 12)
+	if err != nil {
+		t.Fatalf("OpenArtifactByID error: %v", err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil || string(content) != "hello" {
+		t.Fatalf("unexpected content %q: %v", content, err)
+	}
+	if err := reader.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	if urlRequests != 2 || fileRequests != 2 || reader.Size != 5 {
+		t.Fatalf("expired urls must be refreshed once: url=%d file=%d size=%d", urlRequests, fileRequests, reader.Size)
+	}
+
+	fileHash = "00000000000000000000000000000000"
+	reader, err = service.OpenArtifactByID(context.Background(), 12)
+	if err != nil {
+		t.Fatalf("OpenArtifactByID error: %v", err)
+	}
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	err = reader.Close()
+	var sdkErr *utils.SDKError
+	if !errors.As(err, &sdkErr) || sdkErr.Code != utils.ErrCodeChecksumMismatch {
+		t.Fatalf("checksum mismatches must surface on Close, got %v", err)
+	}
+
+	reader, err = service.OpenArtifactByID(context.Background(), 12)
+	if err != nil {
+		t.Fatalf("OpenArtifactByID error: %v", err)
+	}
+	err = reader.Close()
+	if !errors.As(err, &sdkErr) || sdkErr.Code != utils.ErrCodeUnverified {
+		t.Fatalf("closing an unread stream must report it unverified, got %v", err)
+	}
+}
+
+func TestOpenArtifactVerifiesSignedDigest(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	content := "hello"
+	extra := `{}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":12,"name":"artifact-a","extra":` + mustJSON(extra) + `}}`))
+		case "/aiplorer/artifact/download-url":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"downloadUrl":"http://` + r.Host + `/files/artifact.bin","fileName":"artifact.bin"}}`))
+		case "/files/artifact.bin":
+			_, _ = w.Write([]byte(content))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+	httpClient, err := client.NewHTTPClient(&client.Config{BaseURL: server.URL, ArtifactTrustedKeys: map[string]ed25519.PublicKey{"release": publicKey}})
+	if err != nil {
+		t.Fatalf("new http client: %v", err)
+	}
+	service := NewArtifactService(httpClient).(*artifactService)
+
+	var sdkErr *utils.SDKError
+	if _, err := service.OpenArtifactByID(context.Background(), 12); !errors.As(err, &sdkErr) || sdkErr.Code != utils.ErrCodeSignatureInvalid {
+		t.Fatalf("expected unsigned artifact to be refused, got %v", err)
+	}
+
+	digest := sha256.Sum256([]byte("hello"))
+	signature := &models.ArtifactSignature{
+		Algorithm:       artifactSignatureAlgorithm,
+		KeyID:           "release",
+		DigestAlgorithm: "sha256",
+		Digest:          hex.EncodeToString(digest[:]),
+	}
+	signature.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, artifactSignatureMessage(signature)))
+	extra = `{"` + models.ArtifactSignatureExtraKey + `":` + mustJSON(signature) + `}`
+
+	reader, err := service.OpenArtifactByID(context.Background(), 12)
+	if err != nil {
+		t.Fatalf("OpenArtifactByID error: %v", err)
+	}
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if err := reader.Close(); err != nil || reader.Signature == nil || reader.Signature.KeyID != "release" {
+		t.Fatalf("expected signed stream to verify on Close, got %v", err)
+	}
+
+	content = "tampered"
+	reader, err = service.OpenArtifactByID(context.Background(), 12)
+	if err != nil {
+		t.Fatalf("OpenArtifactByID error: %v", err)
+	}
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if err := reader.Close(); !errors.As(err, &sdkErr) || sdkErr.Code != utils.ErrCodeSignatureInvalid {
+		t.Fatalf("expected tampered stream to fail the signed digest, got %v", err)
+	}
+}
+
+func TestOpenArtifactAbortsStalledStreamWithContext(t *testing.T) {
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":12,"name":"artifact-a","fileHash":"` + helloMD5 + `"}}`))
+		case "/aiplorer/artifact/download-url":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"downloadUrl":"http://` + r.Host + `/files/artifact.bin","fileName":"artifact.bin"}}`))
+		case "/files/artifact.bin":
+			_, _ = w.Write([]byte("he"))
+			w.(http.Flusher).Flush()
+			// The storage stalls until the client gives up.
+			<-r.Context().Done()
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader, err := service.OpenArtifactByID(ctx, 12)
+	if err != nil {
+		t.Fatalf("OpenArtifactByID error: %v", err)
+	}
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	cancel()
+	if _, err := io.Copy(io.Discard, reader); err == nil {
+		t.Fatal("reads must fail once the context is cancelled")
+	}
+	var sdkErr *utils.SDKError
+	if err := reader.Close(); !errors.As(err, &sdkErr) || sdkErr.Code != utils.ErrCodeUnverified {
+		t.Fatalf("aborted streams must close without downloading the rest, got %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+const defaultPromotionStateKey = "promotion"
+
+// PromoteArtifact moves an artifact to a workflow stage. It refuses
+// transitions the workflow does not allow and stages whose gates fail, then
+// writes the stage tags, validated against the tag schema like any tag
+// update, and, in Extra, the promotion record. The write is
+// conditional on the artifact being unchanged since it was read, as in
+// PatchArtifactTags, and is redone on the latest artifact after a conflict.
+func (s *artifactService) PromoteArtifact(req *models.ArtifactPromotionReq) (*models.ArtifactPromotionResult, error) {
+	if req == nil {
+		return nil, utils.NewInvalidInputError("artifact promotion request is nil", nil)
+	}
+	if strings.TrimSpace(req.PromotedBy) == "" {
+		return nil, utils.NewInvalidInputError("promotedBy is required for artifact promotion", nil)
+	}
+	if err := validatePromotionWorkflow(req.Workflow); err != nil {
+		return nil, err
+	}
+	targetIndex := promotionStageIndex(req.Workflow, req.ToStage)
+	if targetIndex < 0 {
+		return nil, utils.NewInvalidInputError(fmt.Sprintf("unknown promotion stage: %s", req.ToStage), nil)
+	}
+	target := req.Workflow.Stages[targetIndex]
+	stateKey := promotionStateKey(req.Workflow)
+
+	result := &models.ArtifactPromotionResult{ArtifactID: req.ArtifactID}
+	schemas := map[string]*models.ArtifactTagSchemaInfo{}
+	response, _, err := s.updateArtifactIfUnchanged(req.ArtifactID, defaultTagPatchMaxRetries, func(artifact *models.ArtifactInfo) (*models.ArtifactInfo, error) {
+		if artifact.ID == nil {
+			artifact.ID = &req.ArtifactID
+		}
+		tags, err := artifactTagsMap(artifact)
+		if err != nil {
+			return nil, err
+		}
+		extra, err := artifactExtraMap(artifact)
+		if err != nil {
+			return nil, err
+		}
+		state, err := promotionStateOf(tags, extra, stateKey)
+		if err != nil {
+			return nil, err
+		}
+		if !promotionAllowed(target, state.Stage) {
+			from := state.Stage
+			if from == "" {
+				from = "<none>"
+			}
+			return nil, utils.NewConflictError(fmt.Sprintf("illegal promotion of artifact %d from %s to %s", req.ArtifactID, from, target.Name), nil)
+		}
+
+		record := models.ArtifactPromotionRecord{
+			From: state.Stage,
+			To:   target.Name,
+			By:   req.PromotedBy,
+			At:   time.Now().Unix(),
+			Note: req.Note,
+		}
+		state.History = append(state.History, record)
+		state.Stage = target.Name
+		state.PromotedBy = record.By
+		state.PromotedAt = record.At
+
+		// State written into the tags by earlier releases moves to Extra;
+		// an unrelated tag that happens to use the key is kept.
+		if isLegacyPromotionState(tags[stateKey]) {
+			delete(tags, stateKey)
+		}
+		promoted := tags
+		if len(target.Tags) > 0 {
+			patched, ok := applyMergePatch(tags, cloneJSONValue(target.Tags)).(map[string]any)
+			if !ok {
+				return nil, utils.NewInvalidInputError(fmt.Sprintf("stage %s tags must be an object", target.Name), nil)
+			}
+			promoted = patched
+		}
+		var stateValue any
+		if err := remarshalJSON(state, &stateValue); err != nil {
+			return nil, utils.NewInternalError("failed to encode promotion state", err)
+		}
+		extra[stateKey] = stateValue
+
+		// Resolve the schema the way UpdateArtifactTags does.
+		tagSchemaVersion := req.TagSchemaVersion
+		if tagSchemaVersion == "" {
+			if rawVersion, ok := promoted["schema_version"].(string); ok {
+				tagSchemaVersion = rawVersion
+			}
+		}
+		if tagSchemaVersion == "" {
+			tagSchemaVersion = valueOrEmpty(artifact.TagSchemaVersion)
+		}
+		schema, ok := schemas[tagSchemaVersion]
+		if !ok {
+			schema, err = s.GetArtifactTagSchema(tagSchemaVersion)
+			if err != nil {
+				return nil, err
+			}
+			schemas[tagSchemaVersion] = schema
+		}
+		for _, gate := range target.Gates {
+			if promoted, err = s.checkPromotionGate(gate, artifact, promoted, schema, req.Workflow, targetIndex); err != nil {
+				return nil, err
+			}
+		}
+		// Promoted tags are validated whatever the gates, as any tag update.
+		if promoted, err = s.validateArtifactTagsWithSchema(promoted, schema); err != nil {
+			return nil, err
+		}
+
+		result.Record = record
+		result.State = *state
+		result.Tags = promoted
+		return &models.ArtifactInfo{
+			Tags:             stringPtr(mustJSON(promoted)),
+			Extra:            stringPtr(mustJSON(extra)),
+			TagSchemaVersion: stringPtr(tagSchemaVersion),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Response = response
+	return result, nil
+}
+
+// GetArtifactPromotionState returns the promotion state stored in an
+// artifact's Extra. Artifacts that were never promoted have an empty stage.
+func (s *artifactService) GetArtifactPromotionState(artifactID uint64, workflow *models.ArtifactPromotionWorkflow) (*models.ArtifactPromotionState, error) {
+	artifact, err := s.GetArtifactByID(artifactID)
+	if err != nil {
+		return nil, err
+	}
+	return artifactPromotionState(artifact, promotionStateKey(workflow))
+}
+
+// checkPromotionGate checks one gate and returns the tags to write: the
+// schema_valid gate upgrades them to the schema version it validated.
+func (s *artifactService) checkPromotionGate(gate models.ArtifactPromotionGate, artifact *models.ArtifactInfo, tags map[string]any, schema *models.ArtifactTagSchemaInfo, workflow *models.ArtifactPromotionWorkflow, targetIndex int) (map[string]any, error) {
+	artifactID := *artifact.ID
+	switch gate {
+	case models.ArtifactPromotionGateChecksumPresent:
+		if strings.TrimSpace(valueOrEmpty(artifact.FileHash)) == "" {
+			return nil, utils.NewConflictError(fmt.Sprintf("promotion gate %s failed: artifact %d has no file hash", gate, artifactID), nil)
+		}
+	case models.ArtifactPromotionGateSchemaValid:
+		validated, err := s.validateArtifactTagsWithSchema(tags, schema)
+		if err != nil {
+			return nil, utils.NewConflictError(fmt.Sprintf("promotion gate %s failed for artifact %d", gate, artifactID), err)
+		}
+		return validated, nil
+	case models.ArtifactPromotionGateDependenciesPromoted:
+		stateKey := promotionStateKey(workflow)
+		var behind []string
+		for _, dependency := range artifact.Dependencies {
+			if dependency.ID == nil {
+				continue
+			}
+			detail, err := s.GetArtifactByID(*dependency.ID)
+			if err != nil {
+				return nil, err
+			}
+			state, err := artifactPromotionState(detail, stateKey)
+			if err != nil {
+				return nil, err
+			}
+			if promotionStageIndex(workflow, state.Stage) < targetIndex {
+				behind = append(behind, fmt.Sprintf("%d (%s)", *dependency.ID, state.Stage))
+			}
+		}
+		if len(behind) > 0 {
+			return nil, utils.NewConflictError(fmt.Sprintf("promotion gate %s failed: dependencies not promoted to %s: %s", gate, workflow.Stages[targetIndex].Name, strings.Join(behind, ", ")), nil)
+		}
+	default:
+		return nil, utils.NewInvalidInputError(fmt.Sprintf("unknown promotion gate: %s", gate), nil)
+	}
+	return tags, nil
+}
+
+func validatePromotionWorkflow(workflow *models.ArtifactPromotionWorkflow) error {
+	if workflow == nil || len(workflow.Stages) == 0 {
+		return utils.NewInvalidInputError("promotion workflow requires at least one stage", nil)
+	}
+	seen := map[string]bool{}
+	for _, stage := range workflow.Stages {
+		if strings.TrimSpace(stage.Name) == "" {
+			return utils.NewInvalidInputError("promotion stage name is required", nil)
+		}
+		if seen[stage.Name] {
+			return utils.NewInvalidInputError(fmt.Sprintf("duplicate promotion stage: %s", stage.Name), nil)
+		}
+		seen[stage.Name] = true
+	}
+	for _, stage := range workflow.Stages {
+		for _, from := range stage.From {
+			if !seen[from] {
+				return utils.NewInvalidInputError(fmt.Sprintf("promotion stage %s allows unknown stage %s", stage.Name, from), nil)
+			}
+		}
+	}
+	return nil
+}
+
+func promotionAllowed(target models.ArtifactPromotionStage, current string) bool {
+	if len(target.From) == 0 {
+		return current == ""
+	}
+	for _, from := range target.From {
+		if from == current {
+			return true
+		}
+	}
+	return false
+}
+
+func promotionStageIndex(workflow *models.ArtifactPromotionWorkflow, name string) int {
+	for i, stage := range workflow.Stages {
+		if stage.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func promotionStateKey(workflow *models.ArtifactPromotionWorkflow) string {
+	if workflow != nil && workflow.StateKey != "" {
+		return workflow.StateKey
+	}
+	return defaultPromotionStateKey
+}
+
+func artifactPromotionState(artifact *models.ArtifactInfo, stateKey string) (*models.ArtifactPromotionState, error) {
+	tags, err := artifactTagsMap(artifact)
+	if err != nil {
+		return nil, err
+	}
+	extra, err := artifactExtraMap(artifact)
+	if err != nil {
+		return nil, err
+	}
+	return promotionStateOf(tags, extra, stateKey)
+}
+
+// promotionStateOf reads the promotion state from Extra, falling back to a
+// state object in the tags where earlier releases stored it.
+func promotionStateOf(tags, extra map[string]any, stateKey string) (*models.ArtifactPromotionState, error) {
+	state := &models.ArtifactPromotionState{}
+	value, ok := extra[stateKey]
+	if !ok || value == nil {
+		if !isLegacyPromotionState(tags[stateKey]) {
+			return state, nil
+		}
+		value = tags[stateKey]
+	}
+	if err := remarshalJSON(value, state); err != nil {
+		return nil, utils.NewAPIError(fmt.Sprintf("invalid promotion state in %s", stateKey), err)
+	}
+	return state, nil
+}
+
+// isLegacyPromotionState reports whether a tag value is a promotion state
+// object written by earlier releases rather than a user tag.
+func isLegacyPromotionState(value any) bool {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return false
+	}
+	_, ok = object["stage"].(string)
+	return ok
+}
+
+func artifactTagsMap(artifact *models.ArtifactInfo) (map[string]any, error) {
+	if artifact.Tags == nil || strings.TrimSpace(*artifact.Tags) == "" {
+		return map[string]any{}, nil
+	}
+	tags, err := models.ParseJSON(*artifact.Tags)
+	if err != nil {
+		return nil, utils.NewAPIError("failed to decode artifact tags", err)
+	}
+	return tags, nil
+}
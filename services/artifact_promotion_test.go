@@ -0,0 +1,151 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+func testPromotionWorkflow() *models.ArtifactPromotionWorkflow {
+	return &models.ArtifactPromotionWorkflow{Stages: []models.ArtifactPromotionStage{
+		{Name: "dev", Tags: map[string]any{"decision": "pending"}},
+		{Name: "test", From: []string{"dev"}, Gates: []models.ArtifactPromotionGate{
+			models.ArtifactPromotionGateChecksumPresent,
+			models.ArtifactPromotionGateSchemaValid,
+		}},
+		{Name: "release", From: []string{"test"}, Tags: map[string]any{"decision": "pass"}, Gates: []models.ArtifactPromotionGate{
+			models.ArtifactPromotionGateDependenciesPromoted,
+		}},
+	}}
+}
+
+func TestPromoteArtifactAcrossStages(t *testing.T) {
+	strictSchema := strings.Replace(testTagSchema, `"type":"object",`, `"type":"object","additionalProperties":false,`, 1)
+	tags := map[uint64]string{
+		1: `{"schema_version":"0.2.0","decision":"pending"}`,
+		// Artifact 2 was promoted by a release that kept the state in tags.
+		2: `{"schema_version":"0.2.0","decision":"pending","promotion":{"stage":"test"}}`,
+	}
+	extras := map[uint64]string{}
+	updatedAt := map[uint64]int64{1: 100, 2: 100}
+	racing := false
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeBody(t, r)
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			id := uint64(payload["id"].(float64))
+			body := map[string]any{"id": id, "tagSchemaVersion": "0.2.0", "tags": tags[id], "extra": extras[id], "fileHash": helloMD5, "updatedAt": updatedAt[id]}
+			if id == 1 {
+				body["dependencies"] = []map[string]any{{"id": 2}}
+			}
+			_, _ = w.Write([]byte(`{"code":0,"data":` + mustJSON(body) + `}`))
+		case "/aiplorer/artifact/tag-schema":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"version":"0.2.0","content":` + mustJSON(strictSchema) + `}}`))
+		case "/aiplorer/artifact/update":
+			id := uint64(payload["id"].(float64))
+			if racing {
+				// Another writer updates the artifact after it was read.
+				racing = false
+				updatedAt[id]++
+			}
+			if payload["expectedUpdatedAt"] != float64(updatedAt[id]) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			tags[id] = payload["tags"].(string)
+			extras[id] = payload["extra"].(string)
+			updatedAt[id]++
+			_, _ = w.Write([]byte(`{"code":0,"msg":"updated"}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+	workflow := testPromotionWorkflow()
+	promote := func(stage string) error {
+		_, err := service.PromoteArtifact(&models.ArtifactPromotionReq{
+			Workflow:   workflow,
+			ArtifactID: 1,
+			ToStage:    stage,
+			PromotedBy: "alice",
+		})
+		return err
+	}
+
+	var sdkErr *utils.SDKError
+	if err := promote("release"); !errors.As(err, &sdkErr) || sdkErr.Code != utils.ErrCodeConflict {
+		t.Fatalf("expected illegal transition to be refused, got %v", err)
+	}
+	if err := promote("dev"); err != nil {
+		t.Fatalf("promote to dev: %v", err)
+	}
+	racing = true
+	if err := promote("test"); err != nil {
+		t.Fatalf("promote to test after a concurrent write: %v", err)
+	}
+	if err := promote("release"); !errors.As(err, &sdkErr) || sdkErr.Code != utils.ErrCodeConflict {
+		t.Fatalf("expected dependency gate to fail, got %v", err)
+	}
+
+	extras[2] = `{"promotion":{"stage":"release"}}`
+	if err := promote("release"); err != nil {
+		t.Fatalf("promote to release: %v", err)
+	}
+
+	var written map[string]any
+	if err := json.Unmarshal([]byte(tags[1]), &written); err != nil {
+		t.Fatalf("decode tags: %v", err)
+	}
+	if written["decision"] != "pass" {
+		t.Fatalf("expected release stage tags to be applied: %#v", written)
+	}
+	if _, ok := written["promotion"]; ok {
+		t.Fatalf("promotion state must stay out of the validated tags: %#v", written)
+	}
+	state, err := service.GetArtifactPromotionState(1, workflow)
+	if err != nil {
+		t.Fatalf("GetArtifactPromotionState error: %v", err)
+	}
+	if state.Stage != "release" || state.PromotedBy != "alice" || state.PromotedAt == 0 || len(state.History) != 3 || state.History[2].From != "test" {
+		t.Fatalf("unexpected promotion state: %#v", state)
+	}
+}
+
+func TestPromoteArtifactValidatesTagsWithoutSchemaGate(t *testing.T) {
+	tags := `{"schema_version":"0.2.0","decision":"pending","promotion":"manual"}`
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeBody(t, r)
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":` + mustJSON(map[string]any{"id": 1, "tagSchemaVersion": "0.2.0", "tags": tags, "updatedAt": 100}) + `}`))
+		case "/aiplorer/artifact/tag-schema":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"version":"0.2.0","content":` + mustJSON(testTagSchema) + `}}`))
+		case "/aiplorer/artifact/update":
+			tags = payload["tags"].(string)
+			_, _ = w.Write([]byte(`{"code":0,"msg":"updated"}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+	workflow := &models.ArtifactPromotionWorkflow{Stages: []models.ArtifactPromotionStage{
+		{Name: "dev", Tags: map[string]any{"decision": "pass"}},
+		{Name: "broken", Tags: map[string]any{"decision": "shipped"}},
+	}}
+
+	if _, err := service.PromoteArtifact(&models.ArtifactPromotionReq{Workflow: workflow, ArtifactID: 1, ToStage: "broken", PromotedBy: "alice"}); err == nil {
+		t.Fatal("stage tags violating the schema must be rejected without a schema_valid gate")
+	}
+	if !strings.Contains(tags, `"decision":"pending"`) {
+		t.Fatalf("rejected promotions must not write tags: %s", tags)
+	}
+	if _, err := service.PromoteArtifact(&models.ArtifactPromotionReq{Workflow: workflow, ArtifactID: 1, ToStage: "dev", PromotedBy: "alice"}); err != nil {
+		t.Fatalf("promote to dev: %v", err)
+	}
+	if !strings.Contains(tags, `"promotion":"manual"`) || !strings.Contains(tags, `"decision":"pass"`) {
+		t.Fatalf("user tags sharing the state key must be kept: %s", tags)
+	}
+}
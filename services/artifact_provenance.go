@@ -0,0 +1,273 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+const defaultProvenanceBuilderID = "https://github.com/hujia-team/intranet-sdk"
+
+// GenerateArtifactProvenance builds an in-toto statement with an SLSA v1
+// provenance predicate from the artifact's registered build metadata.
+func (s *artifactService) GenerateArtifactProvenance(artifactID uint64) (*models.InTotoStatement, error) {
+	artifact, err := s.GetArtifactByID(artifactID)
+	if err != nil {
+		return nil, err
+	}
+	if artifact.ID == nil {
+		return nil, utils.NewAPIError("artifact id is empty", nil)
+	}
+	digest, err := provenanceDigest(valueOrEmpty(artifact.FileHash))
+	if err != nil {
+		return nil, utils.NewAPIError(fmt.Sprintf("artifact %d has no usable file hash for provenance", *artifact.ID), err)
+	}
+
+	parameters := map[string]any{"artifactId": *artifact.ID}
+	for key, value := range map[string]string{
+		"name":            valueOrEmpty(artifact.Name),
+		"type":            valueOrEmpty(artifact.Type),
+		"platform":        valueOrEmpty(artifact.Platform),
+		"projectName":     valueOrEmpty(artifact.ProjectName),
+		"commitHash":      valueOrEmpty(artifact.CommitHash),
+		"semanticVersion": valueOrEmpty(artifact.SemanticVersion),
+		"fullPath":        valueOrEmpty(artifact.FullPath),
+	} {
+		if value != "" {
+			parameters[key] = value
+		}
+	}
+
+	dependencies := make([]models.ResourceDescriptor, 0, len(artifact.Commits))
+	for _, commit := range artifact.Commits {
+		hash := valueOrEmpty(commit.CommitHash)
+		if hash == "" {
+			continue
+		}
+		descriptor := models.ResourceDescriptor{
+			Name:   valueOrEmpty(commit.RepositoryName),
+			Digest: map[string]string{"gitCommit": hash},
+		}
+		if path := valueOrEmpty(commit.RepositoryPath); path != "" {
+			descriptor.URI = "git+" + path
+			if branch := valueOrEmpty(commit.Branch); branch != "" {
+				descriptor.URI += "@refs/heads/" + branch
+			}
+		}
+		dependencies = append(dependencies, descriptor)
+	}
+
+	runDetails := models.SLSARunDetails{Builder: models.SLSABuilder{ID: provenanceBuilderID(valueOrEmpty(artifact.PipelineURL))}}
+	metadata := &models.SLSABuildMetadata{InvocationID: valueOrEmpty(artifact.PipelineURL)}
+	if metadata.InvocationID == "" {
+		metadata.InvocationID = valueOrEmpty(artifact.PipelineID)
+	}
+	if artifact.BuildDate != nil && *artifact.BuildDate > 0 {
+		metadata.StartedOn = unixTime(*artifact.BuildDate).UTC().Format(time.RFC3339)
+	}
+	if metadata.InvocationID != "" || metadata.StartedOn != "" {
+		runDetails.Metadata = metadata
+	}
+
+	return &models.InTotoStatement{
+		Type: models.InTotoStatementType,
+		Subject: []models.ResourceDescriptor{{
+			Name:   valueOrEmpty(artifact.Name),
+			Digest: digest,
+		}},
+		PredicateType: models.SLSAProvenancePredicate,
+		Predicate: models.SLSAProvenance{
+			BuildDefinition: models.SLSABuildDefinition{
+				BuildType:            models.ArtifactProvenanceBuildType,
+				ExternalParameters:   parameters,
+				ResolvedDependencies: dependencies,
+			},
+			RunDetails: runDetails,
+		},
+	}, nil
+}
+
+// SignArtifactProvenance generates the provenance statement for an artifact
+// and wraps it in a DSSE envelope signed with the given ed25519 key.
+func (s *artifactService) SignArtifactProvenance(artifactID uint64, key ed25519.PrivateKey, keyID string) (*models.DSSEEnvelope, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, utils.NewInvalidInputError("invalid ed25519 private key", nil)
+	}
+	statement, err := s.GenerateArtifactProvenance(artifactID)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return nil, utils.NewInternalError("failed to encode provenance statement", err)
+	}
+	signature := ed25519.Sign(key, dssePAE(models.InTotoPayloadType, payload))
+	return &models.DSSEEnvelope{
+		PayloadType: models.InTotoPayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []models.DSSESignature{{
+			KeyID: keyID,
+			Sig:   base64.StdEncoding.EncodeToString(signature),
+		}},
+	}, nil
+}
+
+// VerifyArtifactProvenance checks the envelope signature, compares the
+// statement subject with the digest of a downloaded file and compares the
+// resolved source commits with the commits registered for the artifact.
+func (s *artifactService) VerifyArtifactProvenance(envelope *models.DSSEEnvelope, publicKey ed25519.PublicKey, filePath string) (*models.ArtifactProvenanceVerification, error) {
+	if envelope == nil {
+		return nil, utils.NewInvalidInputError("provenance envelope is nil", nil)
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, utils.NewInvalidInputError("invalid ed25519 public key", nil)
+	}
+	if envelope.PayloadType != models.InTotoPayloadType {
+		return nil, utils.NewInvalidInputError(fmt.Sprintf("unsupported provenance payload type: %s", envelope.PayloadType), nil)
+	}
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, utils.NewInvalidInputError("invalid provenance payload encoding", err)
+	}
+
+	result := &models.ArtifactProvenanceVerification{}
+	message := dssePAE(envelope.PayloadType, payload)
+	for _, signature := range envelope.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(signature.Sig)
+		if err == nil && ed25519.Verify(publicKey, message, sig) {
+			result.SignatureValid = true
+			break
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var statement models.InTotoStatement
+	if err := decoder.Decode(&statement); err != nil {
+		return nil, utils.NewInvalidInputError("invalid provenance statement", err)
+	}
+	if statement.Type != models.InTotoStatementType || statement.PredicateType != models.SLSAProvenancePredicate {
+		return nil, utils.NewInvalidInputError(fmt.Sprintf("unsupported provenance statement: %s / %s", statement.Type, statement.PredicateType), nil)
+	}
+	result.Statement = &statement
+
+	for _, subject := range statement.Subject {
+		for _, expected := range subject.Digest {
+			matched, err := verifyFileHash(filePath, expected)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				result.SubjectMatched = true
+			}
+		}
+	}
+
+	artifactID, err := strconv.ParseUint(fmt.Sprint(statement.Predicate.BuildDefinition.ExternalParameters["artifactId"]), 10, 64)
+	if err != nil {
+		return nil, utils.NewInvalidInputError("provenance statement has no artifactId parameter", err)
+	}
+	artifact, err := s.GetArtifactByID(artifactID)
+	if err != nil {
+		return nil, err
+	}
+	registered := map[string]bool{}
+	for _, commit := range artifact.Commits {
+		if hash := valueOrEmpty(commit.CommitHash); hash != "" {
+			registered[strings.ToLower(hash)] = true
+		}
+	}
+	attested := map[string]bool{}
+	for _, dependency := range statement.Predicate.BuildDefinition.ResolvedDependencies {
+		if hash := dependency.Digest["gitCommit"]; hash != "" {
+			attested[strings.ToLower(hash)] = true
+		}
+	}
+	for hash := range registered {
+		if !attested[hash] {
+			result.MissingCommits = append(result.MissingCommits, hash)
+		}
+	}
+	for hash := range attested {
+		if !registered[hash] {
+			result.UnknownCommits = append(result.UnknownCommits, hash)
+		}
+	}
+	sort.Strings(result.MissingCommits)
+	sort.Strings(result.UnknownCommits)
+	result.CommitsMatched = len(result.MissingCommits) == 0 && len(result.UnknownCommits) == 0
+	result.Verified = result.SignatureValid && result.SubjectMatched && result.CommitsMatched
+	return result, nil
+}
+
+// ParseEd25519PrivateKey parses a PEM encoded PKCS#8 ed25519 private key.
+func ParseEd25519PrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, utils.NewInvalidInputError("no PEM block found in private key", nil)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, utils.NewInvalidInputError("failed to parse private key", err)
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, utils.NewInvalidInputError("private key is not an ed25519 key", nil)
+	}
+	return privateKey, nil
+}
+
+// ParseEd25519PublicKey parses a PEM encoded PKIX ed25519 public key.
+func ParseEd25519PublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, utils.NewInvalidInputError("no PEM block found in public key", nil)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, utils.NewInvalidInputError("failed to parse public key", err)
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, utils.NewInvalidInputError("public key is not an ed25519 key", nil)
+	}
+	return publicKey, nil
+}
+
+// dssePAE is the DSSE pre-authentication encoding that signatures cover.
+func dssePAE(payloadType string, payload []byte) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "DSSEv1 %d %s %d ", len(payloadType), payloadType, len(payload))
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+// provenanceDigest maps an artifact file hash to an in-toto digest set.
+func provenanceDigest(fileHash string) (map[string]string, error) {
+	fileHash = strings.ToLower(strings.TrimSpace(fileHash))
+	algorithm := fileHashAlgorithm(fileHash)
+	if algorithm == "" {
+		return nil, fmt.Errorf("unsupported file hash length %d", len(fileHash))
+	}
+	return map[string]string{algorithm: fileHash}, nil
+}
+
+func provenanceBuilderID(pipelineURL string) string {
+	parsed, err := url.Parse(pipelineURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return defaultProvenanceBuilderID
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
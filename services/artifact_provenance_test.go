@@ -0,0 +1,80 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignAndVerifyArtifactProvenance(t *testing.T) {
+	commits := `[{"commitHash":"aaa111","repositoryName":"vision","repositoryPath":"git@host:team/vision.git","branch":"main"}]`
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/aiplorer/artifact" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"code":0,"data":{"id":9,"name":"vision.zip","fileHash":"` + helloMD5 + `",` +
+			`"pipelineId":"42","pipelineUrl":"https://ci.example.com/p/42","buildDate":1700000000,"commits":` + commits + `}}`))
+	})
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(privateKey)
+	parsedKey, err := ParseEd25519PrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("ParseEd25519PrivateKey error: %v", err)
+	}
+
+	envelope, err := service.SignArtifactProvenance(9, parsedKey, "ci")
+	if err != nil {
+		t.Fatalf("SignArtifactProvenance error: %v", err)
+	}
+
+	filePath := filepath.Join(t.TempDir(), "vision.zip")
+	if err := os.WriteFile(filePath, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	result, err := service.VerifyArtifactProvenance(envelope, publicKey, filePath)
+	if err != nil {
+		t.Fatalf("VerifyArtifactProvenance error: %v", err)
+	}
+	if !result.Verified {
+		t.Fatalf("expected provenance to verify: %#v", result)
+	}
+	predicate := result.Statement.Predicate
+	if predicate.RunDetails.Builder.ID != "https://ci.example.com" || predicate.RunDetails.Metadata.StartedOn != "2023-11-14T22:13:20Z" {
+		t.Fatalf("unexpected run details: %#v", predicate.RunDetails)
+	}
+	if uri := predicate.BuildDefinition.ResolvedDependencies[0].URI; uri != "git+git@host:team/vision.git@refs/heads/main" {
+		t.Fatalf("unexpected dependency uri: %s", uri)
+	}
+
+	if err := os.WriteFile(filePath, []byte("tampered"), 0o644); err != nil {
+		t.Fatalf("tamper file: %v", err)
+	}
+	result, err = service.VerifyArtifactProvenance(envelope, publicKey, filePath)
+	if err != nil || result.Verified || result.SubjectMatched || !result.SignatureValid {
+		t.Fatalf("expected subject mismatch: %#v %v", result, err)
+	}
+
+	otherKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	result, err = service.VerifyArtifactProvenance(envelope, otherKey, filePath)
+	if err != nil || result.SignatureValid {
+		t.Fatalf("expected signature mismatch: %#v %v", result, err)
+	}
+
+	commits = `[{"commitHash":"bbb222"}]`
+	if err := os.WriteFile(filePath, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("restore file: %v", err)
+	}
+	result, err = service.VerifyArtifactProvenance(envelope, publicKey, filePath)
+	if err != nil || result.CommitsMatched || len(result.MissingCommits) != 1 || len(result.UnknownCommits) != 1 {
+		t.Fatalf("expected commit mismatch: %#v %v", result, err)
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+const defaultRetentionBatchSize = 50
+
+// ApplyArtifactRetention evaluates retention rules over every artifact matched
+// by the filter and deletes the expired ones in batches unless DryRun is set.
+func (s *artifactService) ApplyArtifactRetention(req *models.ArtifactRetentionReq) (*models.ArtifactRetentionReport, error) {
+	if req == nil {
+		return nil, utils.NewInvalidInputError("artifact retention request is nil", nil)
+	}
+	if req.KeepLast < 0 || req.MaxAgeDays < 0 || req.BatchSize < 0 {
+		return nil, utils.NewInvalidInputError("artifact retention limits must not be negative", nil)
+	}
+	if req.KeepLast == 0 && req.MaxAgeDays == 0 {
+		return nil, utils.NewInvalidInputError("artifact retention requires keepLast or maxAgeDays", nil)
+	}
+	batchSize := req.BatchSize
+	if batchSize == 0 {
+		batchSize = defaultRetentionBatchSize
+	}
+
+	var artifacts []models.ArtifactInfo
+	if err := s.forEachArtifact(req.Filter, func(item models.ArtifactInfo) (bool, error) {
+		if item.ID != nil {
+			artifacts = append(artifacts, item)
+		}
+		return true, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	groups := map[string][]int{}
+	for i, item := range artifacts {
+		key := valueOrEmpty(item.Name) + "\x00" + valueOrEmpty(item.Platform)
+		groups[key] = append(groups[key], i)
+	}
+	rank := make([]int, len(artifacts))
+	for _, indexes := range groups {
+		sort.SliceStable(indexes, func(a, b int) bool {
+			left, right := artifacts[indexes[a]], artifacts[indexes[b]]
+			if lt, rt := artifactTimestamp(left), artifactTimestamp(right); lt != rt {
+				return lt > rt
+			}
+			return *left.ID > *right.ID
+		})
+		for position, index := range indexes {
+			rank[index] = position
+		}
+	}
+
+	report := &models.ArtifactRetentionReport{
+		DryRun:    req.DryRun,
+		Evaluated: len(artifacts),
+		Decisions: make([]models.ArtifactRetentionDecision, 0, len(artifacts)),
+	}
+	cutoff := time.Now().AddDate(0, 0, -req.MaxAgeDays)
+	var pending []int
+	for i, item := range artifacts {
+		decision := models.ArtifactRetentionDecision{
+			ArtifactID: *item.ID,
+			Name:       valueOrEmpty(item.Name),
+			Platform:   valueOrEmpty(item.Platform),
+			Action:     models.ArtifactRetentionKeep,
+		}
+		decision.Reason = retentionKeepReason(item, rank[i], req, cutoff)
+		if decision.Reason == "" {
+			detail, err := s.GetArtifactByID(*item.ID)
+			switch {
+			case err != nil:
+				decision.Action = models.ArtifactRetentionFailed
+				decision.Error = err.Error()
+			case len(detail.Dependents) > 0:
+				decision.Reason = "has live dependents"
+				for _, dependent := range detail.Dependents {
+					if dependent.ID != nil {
+						decision.Dependents = append(decision.Dependents, *dependent.ID)
+					}
+				}
+			default:
+				decision.Action = models.ArtifactRetentionWouldDelete
+				decision.Reason = retentionDeleteReason(rank[i], req)
+				pending = append(pending, len(report.Decisions))
+			}
+		}
+		report.Decisions = append(report.Decisions, decision)
+	}
+
+	if !req.DryRun {
+		for start := 0; start < len(pending); start += batchSize {
+			end := start + batchSize
+			if end > len(pending) {
+				end = len(pending)
+			}
+			ids := make([]uint64, 0, end-start)
+			for _, index := range pending[start:end] {
+				ids = append(ids, report.Decisions[index].ArtifactID)
+			}
+			report.Batches++
+			_, err := s.DeleteArtifacts(ids)
+			for _, index := range pending[start:end] {
+				if err != nil {
+					report.Decisions[index].Action = models.ArtifactRetentionFailed
+					report.Decisions[index].Error = err.Error()
+					continue
+				}
+				report.Decisions[index].Action = models.ArtifactRetentionDelete
+			}
+		}
+	}
+
+	for _, decision := range report.Decisions {
+		switch decision.Action {
+		case models.ArtifactRetentionKeep:
+			report.Kept++
+		case models.ArtifactRetentionFailed:
+			report.Failed++
+		case models.ArtifactRetentionWouldDelete:
+			report.WouldDelete++
+		default:
+			report.Deleted++
+		}
+	}
+	return report, nil
+}
+
+// retentionKeepReason returns why an artifact is retained, or an empty string
+// when no keep rule applies.
+func retentionKeepReason(item models.ArtifactInfo, rank int, req *models.ArtifactRetentionReq, cutoff time.Time) string {
+	if rank < req.KeepLast {
+		return fmt.Sprintf("within the last %d of its name/platform", req.KeepLast)
+	}
+	if key, ok := matchRetentionTags(item, req.KeepTags); ok {
+		return fmt.Sprintf("tag %s matches", key)
+	}
+	if req.MaxAgeDays > 0 {
+		timestamp := artifactTimestamp(item)
+		if timestamp == 0 {
+			return "build date unknown"
+		}
+		if !unixTime(timestamp).Before(cutoff) {
+			return fmt.Sprintf("newer than %d days", req.MaxAgeDays)
+		}
+	}
+	return ""
+}
+
+func retentionDeleteReason(rank int, req *models.ArtifactRetentionReq) string {
+	var reasons []string
+	if req.KeepLast > 0 {
+		reasons = append(reasons, fmt.Sprintf("beyond the last %d (position %d)", req.KeepLast, rank+1))
+	}
+	if req.MaxAgeDays > 0 {
+		reasons = append(reasons, fmt.Sprintf("older than %d days", req.MaxAgeDays))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// matchRetentionTags reports the first keep tag present in the artifact's tags.
+// Keys are top-level tag names or JSON pointers such as /decision_basis/result.
+func matchRetentionTags(item models.ArtifactInfo, keepTags map[string]any) (string, bool) {
+	if len(keepTags) == 0 || item.Tags == nil || strings.TrimSpace(*item.Tags) == "" {
+		return "", false
+	}
+	tags, err := models.ParseJSON(*item.Tags)
+	if err != nil {
+		return "", false
+	}
+	keys := make([]string, 0, len(keepTags))
+	for key := range keepTags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var actual any
+		if strings.HasPrefix(key, "/") {
+			path, err := parseJSONPointer(key)
+			if err != nil {
+				continue
+			}
+			if actual, err = jsonPointerGet(tags, path); err != nil {
+				continue
+			}
+		} else {
+			var ok bool
+			if actual, ok = tags[key]; !ok {
+				continue
+			}
+		}
+		if mustJSON(actual) == mustJSON(keepTags[key]) {
+			return fmt.Sprintf("%s=%v", key, keepTags[key]), true
+		}
+	}
+	return "", false
+}
+
+// artifactTimestamp returns the build date of an artifact, falling back to its
+// creation time.
+func artifactTimestamp(item models.ArtifactInfo) int64 {
+	if timestamp := int64Value(item.BuildDate); timestamp > 0 {
+		return timestamp
+	}
+	return int64Value(item.CreatedAt)
+}
+
+// unixTime converts an API timestamp in seconds or milliseconds to a time.
+func unixTime(value int64) time.Time {
+	if value > 1e12 {
+		return time.UnixMilli(value)
+	}
+	return time.Unix(value, 0)
+}
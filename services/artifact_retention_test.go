@@ -0,0 +1,91 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func TestApplyArtifactRetention(t *testing.T) {
+	daysAgo := func(days int) int64 { return time.Now().AddDate(0, 0, -days).Unix() }
+	items := []string{
+		fmt.Sprintf(`{"id":1,"name":"vision","platform":"x9","buildDate":%d}`, daysAgo(1)),
+		fmt.Sprintf(`{"id":2,"name":"vision","platform":"x9","buildDate":%d}`, daysAgo(40)),
+		fmt.Sprintf(`{"id":3,"name":"vision","platform":"x9","buildDate":%d,"tags":"{\"decision\":\"pass\"}"}`, daysAgo(50)),
+		fmt.Sprintf(`{"id":4,"name":"vision","platform":"x9","buildDate":%d}`, daysAgo(60)),
+		fmt.Sprintf(`{"id":5,"name":"lib","createdAt":%d}`, time.Now().AddDate(0, 0, -90).UnixMilli()),
+		fmt.Sprintf(`{"id":6,"name":"lib","buildDate":%d}`, daysAgo(100)),
+	}
+	var deleted [][]float64
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeBody(t, r)
+		switch r.URL.Path {
+		case "/aiplorer/artifact/list":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"total":6,"data":[` + strings.Join(items, ",") + `]}}`))
+		case "/aiplorer/artifact":
+			if payload["id"].(float64) == 4 {
+				_, _ = w.Write([]byte(`{"code":0,"data":{"id":4,"dependents":[{"id":40,"name":"app"}]}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":` + mustJSON(payload["id"]) + `}}`))
+		case "/aiplorer/artifact/delete":
+			var ids []float64
+			for _, id := range payload["ids"].([]any) {
+				ids = append(ids, id.(float64))
+			}
+			deleted = append(deleted, ids)
+			_, _ = w.Write([]byte(`{"code":0,"msg":"deleted"}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	req := &models.ArtifactRetentionReq{
+		KeepLast:   1,
+		KeepTags:   map[string]any{"decision": "pass"},
+		MaxAgeDays: 30,
+		BatchSize:  1,
+		DryRun:     true,
+	}
+	report, err := service.ApplyArtifactRetention(req)
+	if err != nil {
+		t.Fatalf("ApplyArtifactRetention dry run error: %v", err)
+	}
+	actions := map[uint64]string{}
+	for _, decision := range report.Decisions {
+		actions[decision.ArtifactID] = decision.Action
+	}
+	want := map[uint64]string{
+		1: models.ArtifactRetentionKeep,
+		2: models.ArtifactRetentionWouldDelete,
+		3: models.ArtifactRetentionKeep,
+		4: models.ArtifactRetentionKeep,
+		5: models.ArtifactRetentionKeep,
+		6: models.ArtifactRetentionWouldDelete,
+	}
+	for id, action := range want {
+		if actions[id] != action {
+			t.Fatalf("artifact %d action = %q, want %q (%#v)", id, actions[id], action, report.Decisions)
+		}
+	}
+	if report.Decisions[3].Dependents[0] != 40 || len(deleted) != 0 || report.WouldDelete != 2 || report.Deleted != 0 {
+		t.Fatalf("unexpected dry run report: %#v deleted=%v", report, deleted)
+	}
+
+	req.DryRun = false
+	report, err = service.ApplyArtifactRetention(req)
+	if err != nil {
+		t.Fatalf("ApplyArtifactRetention error: %v", err)
+	}
+	if report.Deleted != 2 || report.WouldDelete != 0 || report.Kept != 4 || report.Batches != 2 || len(deleted) != 2 || deleted[0][0] != 2 || deleted[1][0] != 6 {
+		t.Fatalf("unexpected apply report: %#v deleted=%v", report, deleted)
+	}
+
+	if _, err := service.ApplyArtifactRetention(&models.ArtifactRetentionReq{DryRun: true}); err == nil {
+		t.Fatal("expected a policy without keepLast or maxAgeDays to be rejected")
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+// SafeDeleteArtifacts deletes artifacts only when nothing outside the request
+// still depends on them. With Cascade every transitive dependent is removed
+// too, in reverse topological order so that no artifact outlives a dependency.
+func (s *artifactService) SafeDeleteArtifacts(ids []uint64, options *models.ArtifactSafeDeleteOptions) (*models.ArtifactSafeDeleteResult, error) {
+	if len(ids) == 0 {
+		return nil, utils.NewInvalidInputError("artifact ids are required", nil)
+	}
+	if options == nil {
+		options = &models.ArtifactSafeDeleteOptions{}
+	}
+	result := &models.ArtifactSafeDeleteResult{DryRun: options.DryRun, Deleted: []uint64{}}
+
+	artifacts := map[uint64]*models.ArtifactInfo{}
+	queue := append([]uint64(nil), ids...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if _, ok := artifacts[id]; ok {
+			continue
+		}
+		artifact, err := s.GetArtifactByID(id)
+		if err != nil {
+			return nil, err
+		}
+		artifacts[id] = artifact
+		if options.Cascade {
+			for _, dependent := range artifact.Dependents {
+				if dependent.ID != nil {
+					queue = append(queue, *dependent.ID)
+				}
+			}
+		}
+	}
+
+	for _, id := range sortedArtifactIDs(artifacts) {
+		var live []models.ArtifactDependencyInfo
+		for _, dependent := range artifacts[id].Dependents {
+			if dependent.ID == nil {
+				continue
+			}
+			if _, ok := artifacts[*dependent.ID]; !ok {
+				live = append(live, dependent)
+			}
+		}
+		if len(live) > 0 {
+			result.Blocked = append(result.Blocked, models.ArtifactDeleteBlock{
+				ArtifactID: id,
+				Name:       valueOrEmpty(artifacts[id].Name),
+				Dependents: live,
+			})
+		}
+	}
+	if len(result.Blocked) > 0 {
+		names := make([]string, 0, len(result.Blocked))
+		for _, block := range result.Blocked {
+			names = append(names, fmt.Sprintf("%d (%d dependents)", block.ArtifactID, len(block.Dependents)))
+		}
+		return result, utils.NewConflictError("artifacts have live dependents: "+strings.Join(names, ", "), nil)
+	}
+
+	levels, err := artifactDeleteLevels(artifacts)
+	if err != nil {
+		return result, err
+	}
+	for _, level := range levels {
+		if !options.DryRun {
+			if _, err := s.DeleteArtifacts(level); err != nil {
+				return result, err
+			}
+		}
+		result.Deleted = append(result.Deleted, level...)
+	}
+	return result, nil
+}
+
+// artifactDeleteLevels groups artifacts so that each group only contains
+// artifacts whose dependents were in earlier groups.
+func artifactDeleteLevels(artifacts map[uint64]*models.ArtifactInfo) ([][]uint64, error) {
+	pending := map[uint64]int{}
+	dependencies := map[uint64][]uint64{}
+	for id, artifact := range artifacts {
+		for _, dependent := range artifact.Dependents {
+			if dependent.ID == nil || *dependent.ID == id {
+				continue
+			}
+			if _, ok := artifacts[*dependent.ID]; ok {
+				pending[id]++
+				dependencies[*dependent.ID] = append(dependencies[*dependent.ID], id)
+			}
+		}
+	}
+
+	var levels [][]uint64
+	done := map[uint64]bool{}
+	for len(done) < len(artifacts) {
+		var level []uint64
+		for _, id := range sortedArtifactIDs(artifacts) {
+			if !done[id] && pending[id] == 0 {
+				level = append(level, id)
+			}
+		}
+		if len(level) == 0 {
+			return nil, utils.NewConflictError("artifact dependents form a cycle", nil)
+		}
+		for _, id := range level {
+			done[id] = true
+			for _, dependency := range dependencies[id] {
+				pending[dependency]--
+			}
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+func sortedArtifactIDs(artifacts map[uint64]*models.ArtifactInfo) []uint64 {
+	ids := make([]uint64, 0, len(artifacts))
+	for id := range artifacts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
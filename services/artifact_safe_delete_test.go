@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+func TestSafeDeleteArtifacts(t *testing.T) {
+	var deleted [][]float64
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeBody(t, r)
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			switch payload["id"].(float64) {
+			case 1:
+				_, _ = w.Write([]byte(`{"code":0,"data":{"id":1,"name":"lib","dependents":[{"id":2,"name":"app"}]}}`))
+			case 2:
+				_, _ = w.Write([]byte(`{"code":0,"data":{"id":2,"name":"app","dependents":[{"id":3,"name":"bundle"}]}}`))
+			default:
+				_, _ = w.Write([]byte(`{"code":0,"data":{"id":3,"name":"bundle"}}`))
+			}
+		case "/aiplorer/artifact/delete":
+			var ids []float64
+			for _, id := range payload["ids"].([]any) {
+				ids = append(ids, id.(float64))
+			}
+			deleted = append(deleted, ids)
+			_, _ = w.Write([]byte(`{"code":0,"msg":"deleted"}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	result, err := service.SafeDeleteArtifacts([]uint64{1}, nil)
+	var sdkErr *utils.SDKError
+	if !errors.As(err, &sdkErr) || sdkErr.Code != utils.ErrCodeConflict {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	if len(result.Blocked) != 1 || *result.Blocked[0].Dependents[0].ID != 2 || len(deleted) != 0 {
+		t.Fatalf("unexpected blocked result: %#v deleted=%v", result, deleted)
+	}
+
+	result, err = service.SafeDeleteArtifacts([]uint64{1}, &models.ArtifactSafeDeleteOptions{Cascade: true, DryRun: true})
+	if err != nil || len(deleted) != 0 {
+		t.Fatalf("unexpected cascade dry run: %v deleted=%v", err, deleted)
+	}
+	if len(result.Deleted) != 3 || result.Deleted[0] != 3 || result.Deleted[2] != 1 {
+		t.Fatalf("unexpected cascade order: %v", result.Deleted)
+	}
+
+	result, err = service.SafeDeleteArtifacts([]uint64{2, 3}, nil)
+	if err != nil {
+		t.Fatalf("SafeDeleteArtifacts error: %v", err)
+	}
+	if len(deleted) != 2 || deleted[0][0] != 3 || deleted[1][0] != 2 || len(result.Deleted) != 2 {
+		t.Fatalf("unexpected deletions: %v %#v", deleted, result)
+	}
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+// BuildArtifactSBOM builds a CycloneDX bill of materials from an artifact's
+// lineage. Every dependency artifact becomes a component and the parent links
+// in Dependencies become the dependency graph.
+func (s *artifactService) BuildArtifactSBOM(artifactID uint64) (*cdx.BOM, error) {
+	root, err := s.GetArtifactByID(artifactID)
+	if err != nil {
+		return nil, err
+	}
+	if root.ID == nil {
+		return nil, utils.NewAPIError("artifact id is empty", nil)
+	}
+
+	rootComponent := artifactSBOMComponent(root)
+	components := make([]cdx.Component, 0, len(root.Dependencies))
+	children := map[string][]string{rootComponent.BOMRef: {}}
+	order := []string{rootComponent.BOMRef}
+	inBOM := map[uint64]bool{*root.ID: true}
+	for _, dep := range root.Dependencies {
+		if dep.ID != nil {
+			inBOM[*dep.ID] = true
+		}
+	}
+	seen := map[uint64]bool{*root.ID: true}
+	edges := map[[2]string]bool{}
+	for _, dep := range root.Dependencies {
+		if dep.ID == nil || *dep.ID == *root.ID {
+			continue
+		}
+		// A dependency reached through several parents keeps one component
+		// but every edge. A parent outside the BOM would leave a dangling
+		// ref, so such dependencies hang off the root.
+		childRef := artifactBOMRef(*dep.ID)
+		parentRef := rootComponent.BOMRef
+		if dep.ParentID != nil && inBOM[*dep.ParentID] {
+			parentRef = artifactBOMRef(*dep.ParentID)
+		}
+		if _, ok := children[parentRef]; !ok {
+			children[parentRef] = []string{}
+			order = append(order, parentRef)
+		}
+		if edge := [2]string{parentRef, childRef}; parentRef != childRef && !edges[edge] {
+			edges[edge] = true
+			children[parentRef] = append(children[parentRef], childRef)
+		}
+		if seen[*dep.ID] {
+			continue
+		}
+		seen[*dep.ID] = true
+		detail, err := s.GetArtifactByID(*dep.ID)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, artifactSBOMComponent(mergeArtifactDependency(detail, dep)))
+		if _, ok := children[childRef]; !ok {
+			children[childRef] = []string{}
+			order = append(order, childRef)
+		}
+	}
+
+	dependencies := make([]cdx.Dependency, 0, len(order))
+	for _, ref := range order {
+		dependsOn := children[ref]
+		dependency := cdx.Dependency{Ref: ref}
+		if len(dependsOn) > 0 {
+			dependency.Dependencies = &dependsOn
+		}
+		dependencies = append(dependencies, dependency)
+	}
+
+	bom := cdx.NewBOM()
+	bom.SerialNumber = newBOMSerialNumber()
+	bom.Metadata = &cdx.Metadata{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Component: &rootComponent,
+		Tools: &cdx.ToolsChoice{
+			Components: &[]cdx.Component{{Type: cdx.ComponentTypeApplication, Name: "intranet-sdk"}},
+		},
+	}
+	bom.Components = &components
+	bom.Dependencies = &dependencies
+	return bom, nil
+}
+
+// ExportArtifactSBOM writes the artifact's CycloneDX 1.5 SBOM to w.
+func (s *artifactService) ExportArtifactSBOM(artifactID uint64, format models.ArtifactSBOMFormat, w io.Writer) error {
+	var fileFormat cdx.BOMFileFormat
+	switch format {
+	case models.ArtifactSBOMJSON, "":
+		fileFormat = cdx.BOMFileFormatJSON
+	case models.ArtifactSBOMXML:
+		fileFormat = cdx.BOMFileFormatXML
+	default:
+		return utils.NewInvalidInputError(fmt.Sprintf("unsupported SBOM format: %s", format), nil)
+	}
+	bom, err := s.BuildArtifactSBOM(artifactID)
+	if err != nil {
+		return err
+	}
+	if err := cdx.NewBOMEncoder(w, fileFormat).SetPretty(true).EncodeVersion(bom, cdx.SpecVersion1_5); err != nil {
+		return utils.NewInternalError("failed to encode SBOM", err)
+	}
+	return nil
+}
+
+// mergeArtifactDependency fills lineage-only fields that the detail lookup may omit.
+func mergeArtifactDependency(detail *models.ArtifactInfo, dep models.ArtifactDependencyInfo) *models.ArtifactInfo {
+	merged := *detail
+	if merged.Name == nil {
+		merged.Name = dep.Name
+	}
+	if merged.Type == nil {
+		merged.Type = dep.Type
+	}
+	if merged.Platform == nil {
+		merged.Platform = dep.Platform
+	}
+	if merged.CommitHash == nil {
+		merged.CommitHash = dep.CommitHash
+	}
+	if merged.ModulePath == nil {
+		merged.ModulePath = dep.ModulePath
+	}
+	if len(merged.Commits) == 0 {
+		merged.Commits = dep.Commits
+	}
+	return &merged
+}
+
+func artifactSBOMComponent(artifact *models.ArtifactInfo) cdx.Component {
+	component := cdx.Component{
+		Type:    cdx.ComponentTypeLibrary,
+		Name:    valueOrEmpty(artifact.Name),
+		Version: valueOrEmpty(artifact.SemanticVersion),
+	}
+	artifactID := ""
+	if artifact.ID != nil {
+		artifactID = strconv.FormatUint(*artifact.ID, 10)
+		component.BOMRef = artifactBOMRef(*artifact.ID)
+	}
+	if valueOrEmpty(artifact.Type) == "app" {
+		component.Type = cdx.ComponentTypeApplication
+	}
+	if component.Version == "" {
+		component.Version = valueOrEmpty(artifact.CommitHash)
+	}
+	if hash := artifactSBOMHash(valueOrEmpty(artifact.FileHash)); hash != nil {
+		component.Hashes = &[]cdx.Hash{*hash}
+	}
+
+	var properties []cdx.Property
+	for _, property := range []struct{ name, value string }{
+		{"intranet:artifact:id", artifactID},
+		{"intranet:artifact:type", valueOrEmpty(artifact.Type)},
+		{"intranet:artifact:platform", valueOrEmpty(artifact.Platform)},
+		{"intranet:artifact:commitHash", valueOrEmpty(artifact.CommitHash)},
+		{"intranet:artifact:projectName", valueOrEmpty(artifact.ProjectName)},
+		{"intranet:artifact:modulePath", valueOrEmpty(artifact.ModulePath)},
+		{"intranet:artifact:fullPath", valueOrEmpty(artifact.FullPath)},
+	} {
+		if property.value != "" {
+			properties = append(properties, cdx.Property{Name: property.name, Value: property.value})
+		}
+	}
+	if len(properties) > 0 {
+		component.Properties = &properties
+	}
+
+	if len(artifact.Commits) > 0 {
+		commits := make([]cdx.Commit, 0, len(artifact.Commits))
+		for _, commit := range artifact.Commits {
+			item := cdx.Commit{
+				UID:     valueOrEmpty(commit.CommitHash),
+				Message: valueOrEmpty(commit.Message),
+			}
+			if item.Message == "" {
+				item.Message = valueOrEmpty(commit.CommitTitle)
+			}
+			if commit.Author != nil || commit.AuthorEmail != nil || commit.CommittedAt != nil {
+				item.Author = &cdx.IdentifiableAction{
+					Name:  valueOrEmpty(commit.Author),
+					Email: valueOrEmpty(commit.AuthorEmail),
+				}
+				if commit.CommittedAt != nil {
+					item.Author.Timestamp = unixTime(*commit.CommittedAt).UTC().Format(time.RFC3339)
+				}
+			}
+			commits = append(commits, item)
+		}
+		component.Pedigree = &cdx.Pedigree{Commits: &commits}
+	}
+	return component
+}
+
+func artifactBOMRef(id uint64) string {
+	return "artifact:" + strconv.FormatUint(id, 10)
+}
+
+var sbomHashAlgorithms = map[string]cdx.HashAlgorithm{
+	"md5":    cdx.HashAlgoMD5,
+	"sha1":   cdx.HashAlgoSHA1,
+	"sha256": cdx.HashAlgoSHA256,
+	"sha512": cdx.HashAlgoSHA512,
+}
+
+func artifactSBOMHash(fileHash string) *cdx.Hash {
+	fileHash = strings.ToLower(strings.TrimSpace(fileHash))
+	algorithm, ok := sbomHashAlgorithms[fileHashAlgorithm(fileHash)]
+	if !ok {
+		return nil
+	}
+	return &cdx.Hash{Algorithm: algorithm, Value: fileHash}
+}
+
+func newBOMSerialNumber() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func TestExportArtifactSBOM(t *testing.T) {
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/aiplorer/artifact" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		payload := decodeBody(t, r)
+		switch payload["id"].(float64) {
+		case 1:
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":1,"name":"app","type":"app","semanticVersion":"2.0.0","fileHash":"` + helloMD5 + `",` +
+				`"dependencies":[{"id":2,"name":"lib","type":"pkg"},{"id":3,"name":"core","parentId":2,"commits":[{"commitHash":"c0ffee","author":"dev","message":"fix"}]},` +
+				`{"id":4,"name":"util","parentId":2},{"id":4,"name":"util","parentId":3},{"id":5,"name":"orphan","parentId":99}]}}`))
+		case 2:
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":2,"name":"lib","semanticVersion":"1.4.0","platform":"x9"}}`))
+		case 3:
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":3,"name":"core","commitHash":"abc"}}`))
+		case 4, 5:
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":` + mustJSON(payload["id"]) + `}}`))
+		default:
+			t.Fatalf("unexpected artifact id: %#v", payload)
+		}
+	})
+
+	var out bytes.Buffer
+	if err := service.ExportArtifactSBOM(1, models.ArtifactSBOMJSON, &out); err != nil {
+		t.Fatalf("ExportArtifactSBOM error: %v", err)
+	}
+	var bom cdx.BOM
+	if err := cdx.NewBOMDecoder(&out, cdx.BOMFileFormatJSON).Decode(&bom); err != nil {
+		t.Fatalf("decode SBOM: %v", err)
+	}
+	if bom.SpecVersion != cdx.SpecVersion1_5 || bom.Metadata.Component.Type != cdx.ComponentTypeApplication {
+		t.Fatalf("unexpected SBOM metadata: %#v", bom.Metadata)
+	}
+	components := *bom.Components
+	if len(components) != 4 || components[0].Version != "1.4.0" || components[1].Version != "abc" {
+		t.Fatalf("unexpected components: %#v", components)
+	}
+	if commits := *components[1].Pedigree.Commits; len(commits) != 1 || commits[0].UID != "c0ffee" {
+		t.Fatalf("unexpected pedigree: %#v", commits)
+	}
+	if hashes := *bom.Metadata.Component.Hashes; hashes[0].Algorithm != cdx.HashAlgoMD5 || hashes[0].Value != helloMD5 {
+		t.Fatalf("unexpected root hashes: %#v", hashes)
+	}
+	refs := map[string]bool{bom.Metadata.Component.BOMRef: true}
+	for _, component := range components {
+		refs[component.BOMRef] = true
+	}
+	dependsOn := map[string]string{}
+	for _, dependency := range *bom.Dependencies {
+		if !refs[dependency.Ref] {
+			t.Fatalf("dependency entry for unknown ref %s", dependency.Ref)
+		}
+		if dependency.Dependencies != nil {
+			for _, ref := range *dependency.Dependencies {
+				if !refs[ref] {
+					t.Fatalf("dangling dependsOn ref %s in %s", ref, dependency.Ref)
+				}
+			}
+			dependsOn[dependency.Ref] = strings.Join(*dependency.Dependencies, ",")
+		}
+	}
+	want := map[string]string{
+		"artifact:1": "artifact:2,artifact:5",
+		"artifact:2": "artifact:3,artifact:4",
+		"artifact:3": "artifact:4",
+	}
+	if !reflect.DeepEqual(dependsOn, want) {
+		t.Fatalf("unexpected dependency graph: %#v", dependsOn)
+	}
+
+	out.Reset()
+	if err := service.ExportArtifactSBOM(1, models.ArtifactSBOMXML, &out); err != nil {
+		t.Fatalf("ExportArtifactSBOM xml error: %v", err)
+	}
+	if !strings.Contains(out.String(), `xmlns="http://cyclonedx.org/schema/bom/1.5"`) {
+		t.Fatalf("unexpected XML SBOM: %s", out.String())
+	}
+}
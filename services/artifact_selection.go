@@ -0,0 +1,125 @@
+package services
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+var defaultArtifactSelectionOrder = []models.ArtifactSelectionCriterion{
+	models.ArtifactSelectNonVirtualFirst,
+	models.ArtifactSelectNewestBuild,
+}
+
+// FindArtifactsByName returns every artifact whose name matches exactly,
+// across all pages of the list API.
+func (s *artifactService) FindArtifactsByName(name string, lookup *models.ArtifactLookupOptions) ([]models.ArtifactInfo, error) {
+	var matched []models.ArtifactInfo
+	err := s.forEachArtifact(buildNameLookupListRequest(name, lookup), func(item models.ArtifactInfo) (bool, error) {
+		if item.Name != nil && *item.Name == name && item.ID != nil {
+			matched = append(matched, item)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matched, nil
+}
+
+// SelectArtifactByName ranks every artifact matching the name with the
+// strategy and returns the full detail of the best one alongside all
+// candidates.
+func (s *artifactService) SelectArtifactByName(name string, lookup *models.ArtifactLookupOptions, strategy *models.ArtifactSelectionStrategy) (*models.ArtifactNameSelection, error) {
+	if strategy == nil {
+		strategy = &models.ArtifactSelectionStrategy{}
+	}
+	order := strategy.Order
+	if len(order) == 0 {
+		order = defaultArtifactSelectionOrder
+	}
+	for _, criterion := range order {
+		switch criterion {
+		case models.ArtifactSelectNewestBuild, models.ArtifactSelectHighestVersion,
+			models.ArtifactSelectPreferredPlatform, models.ArtifactSelectNonVirtualFirst:
+		default:
+			return nil, utils.NewInvalidInputError(fmt.Sprintf("unknown artifact selection criterion: %s", criterion), nil)
+		}
+	}
+
+	candidates, err := s.FindArtifactsByName(name, lookup)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, utils.NewAPIError(fmt.Sprintf("artifact not found by name: %s", name), nil)
+	}
+	sortArtifactCandidates(candidates, order, strategy.PreferredPlatforms)
+	selected, err := s.GetArtifactByID(*candidates[0].ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ArtifactNameSelection{Selected: selected, Candidates: candidates}, nil
+}
+
+func sortArtifactCandidates(candidates []models.ArtifactInfo, order []models.ArtifactSelectionCriterion, preferredPlatforms []string) {
+	platformRank := make(map[string]int, len(preferredPlatforms))
+	for i, platform := range preferredPlatforms {
+		if _, ok := platformRank[platform]; !ok {
+			platformRank[platform] = i
+		}
+	}
+	rankPlatform := func(item models.ArtifactInfo) int {
+		if rank, ok := platformRank[valueOrEmpty(item.Platform)]; ok {
+			return rank
+		}
+		return len(preferredPlatforms)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		for _, criterion := range order {
+			var result int
+			switch criterion {
+			case models.ArtifactSelectNewestBuild:
+				result = cmp.Compare(artifactTimestamp(b), artifactTimestamp(a))
+			case models.ArtifactSelectHighestVersion:
+				result = compareArtifactVersions(b, a)
+			case models.ArtifactSelectPreferredPlatform:
+				result = cmp.Compare(rankPlatform(a), rankPlatform(b))
+			case models.ArtifactSelectNonVirtualFirst:
+				result = cmp.Compare(virtualRank(a), virtualRank(b))
+			}
+			if result != 0 {
+				return result < 0
+			}
+		}
+		return *a.ID > *b.ID
+	})
+}
+
+func virtualRank(item models.ArtifactInfo) int {
+	if isVirtualArtifact(item.IsVirtual) {
+		return 1
+	}
+	return 0
+}
+
+// compareArtifactVersions orders valid semantic versions above missing or
+// invalid ones.
+func compareArtifactVersions(a, b models.ArtifactInfo) int {
+	versionA, errA := parseSemver(valueOrEmpty(a.SemanticVersion))
+	versionB, errB := parseSemver(valueOrEmpty(b.SemanticVersion))
+	switch {
+	case errA != nil && errB != nil:
+		return 0
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return versionA.compare(versionB)
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func TestSelectArtifactByNameStrategies(t *testing.T) {
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeBody(t, r)
+		switch r.URL.Path {
+		case "/aiplorer/artifact/list":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"total":4,"data":[` +
+				`{"id":1,"name":"vision","platform":"j5","buildDate":300,"semanticVersion":"1.2.0"},` +
+				`{"id":2,"name":"vision","platform":"x9","buildDate":200,"semanticVersion":"1.10.0"},` +
+				`{"id":3,"name":"vision","platform":"x9","buildDate":400,"semanticVersion":"2.0.0","isVirtual":true},` +
+				`{"id":4,"name":"vision-msg","platform":"x9","buildDate":500}]}}`))
+		case "/aiplorer/artifact":
+			id := payload["id"].(float64)
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":` + mustJSON(id) + `,"name":"vision","projectName":"proj-` + mustJSON(id) + `"}}`))
+		case "/aiplorer/jfrog/token":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"access_token":"` + payload["projectName"].(string) + `"}}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	for _, tc := range []struct {
+		strategy models.ArtifactSelectionStrategy
+		want     uint64
+	}{
+		{models.ArtifactSelectionStrategy{}, 1},
+		{models.ArtifactSelectionStrategy{Order: []models.ArtifactSelectionCriterion{models.ArtifactSelectHighestVersion}}, 3},
+		{models.ArtifactSelectionStrategy{Order: []models.ArtifactSelectionCriterion{models.ArtifactSelectNonVirtualFirst, models.ArtifactSelectHighestVersion}}, 2},
+		{models.ArtifactSelectionStrategy{
+			Order:              []models.ArtifactSelectionCriterion{models.ArtifactSelectPreferredPlatform, models.ArtifactSelectNewestBuild},
+			PreferredPlatforms: []string{"x9", "j5"},
+		}, 3},
+	} {
+		selection, err := service.SelectArtifactByName("vision", nil, &tc.strategy)
+		if err != nil {
+			t.Fatalf("SelectArtifactByName(%v) error: %v", tc.strategy.Order, err)
+		}
+		if *selection.Selected.ID != tc.want || len(selection.Candidates) != 3 || *selection.Candidates[0].ID != tc.want {
+			t.Fatalf("SelectArtifactByName(%v) selected %d, want %d", tc.strategy.Order, *selection.Selected.ID, tc.want)
+		}
+	}
+
+	if _, err := service.GetArtifactByName("vision", nil); err == nil || !strings.Contains(err.Error(), "multiple artifacts found by name") {
+		t.Fatalf("lookups without a selection must stay strict, got %v", err)
+	}
+	token, err := service.GetJfrogTokenByArtifactName("vision", &models.ArtifactLookupOptions{
+		Selection: &models.ArtifactSelectionStrategy{
+			Order:              []models.ArtifactSelectionCriterion{models.ArtifactSelectPreferredPlatform},
+			PreferredPlatforms: []string{"j5"},
+		},
+	})
+	if err != nil || token.AccessToken != "proj-1" {
+		t.Fatalf("GetJfrogTokenByArtifactName must use the selection: %#v %v", token, err)
+	}
+}
@@ -2,18 +2,24 @@
 package services
 
 import (
+	"context"
+	"crypto/ed25519"
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/hujia-team/intranet-sdk/client"
 	"github.com/hujia-team/intranet-sdk/models"
 	"github.com/hujia-team/intranet-sdk/utils"
@@ -24,39 +30,92 @@ import (
 	jfrogConfig "github.com/jfrog/jfrog-client-go/config"
 )
 
+const defaultListPageSize = 100
+
 // ArtifactService defines artifact-management operations.
 type ArtifactService interface {
 	CreateArtifact(artifact *models.ArtifactInfo) (*models.BaseMsgResp, error)
 	UpdateArtifact(artifact *models.ArtifactInfo) (*models.BaseMsgResp, error)
 	DeleteArtifacts(ids []uint64) (*models.BaseMsgResp, error)
+	SafeDeleteArtifacts(ids []uint64, options *models.ArtifactSafeDeleteOptions) (*models.ArtifactSafeDeleteResult, error)
+	ApplyArtifactRetention(req *models.ArtifactRetentionReq) (*models.ArtifactRetentionReport, error)
+	ListJfrogFiles(projectName, pattern string) ([]models.JfrogFileInfo, error)
+	ImportJfrogArtifacts(req *models.ArtifactImportReq) (*models.ArtifactImportReport, error)
 	ListArtifacts(req *models.ArtifactListReq) (*models.ArtifactListResp, error)
+	WatchArtifacts(ctx context.Context, options *models.ArtifactWatchOptions, handler func(event models.ArtifactWatchEvent) error) error
+	WatchArtifactsChannel(ctx context.Context, options *models.ArtifactWatchOptions) (<-chan models.ArtifactWatchEvent, <-chan error)
 	GetArtifactByID(id uint64) (*models.ArtifactInfo, error)
 	GetArtifactByCommitHash(commitHash string, lookup *models.ArtifactLookupOptions) (*models.ArtifactInfo, error)
 	GetArtifactByName(name string, lookup *models.ArtifactLookupOptions) (*models.ArtifactInfo, error)
+	FindArtifactsByName(name string, lookup *models.ArtifactLookupOptions) ([]models.ArtifactInfo, error)
+	SelectArtifactByName(name string, lookup *models.ArtifactLookupOptions, strategy *models.ArtifactSelectionStrategy) (*models.ArtifactNameSelection, error)
 	CheckExistsByCommitHash(commitHash string, lookup *models.ArtifactLookupOptions) (bool, error)
 	CheckExistsByName(name string, lookup *models.ArtifactLookupOptions) (bool, error)
+	WaitForArtifactByCommitHash(ctx context.Context, commitHash string, lookup *models.ArtifactLookupOptions, options *models.ArtifactWaitOptions) (*models.ArtifactInfo, error)
+	WaitForArtifactsByCommitHashes(ctx context.Context, commitHashes []string, lookup *models.ArtifactLookupOptions, options *models.ArtifactWaitOptions) (map[string]*models.ArtifactInfo, error)
+	ResolveArtifactVersion(req *models.ArtifactVersionResolveReq) (*models.ArtifactVersionResolution, error)
+	ExpandVirtualArtifact(artifactID uint64, options *models.ArtifactExpandOptions) ([]models.ArtifactInfo, error)
+	FindArtifactDependencies(root *models.ArtifactInfo, query *models.ArtifactDependencyQuery) ([]models.ArtifactInfo, error)
+	FindArtifactDependency(root *models.ArtifactInfo, query *models.ArtifactDependencyQuery) (*models.ArtifactInfo, error)
 	PrepareDownloadByArtifactID(artifactID uint64, destination string) (*models.ArtifactDownloadPlan, error)
 	PrepareDownloadByCommitHash(commitHash string, lookup *models.ArtifactLookupOptions, destination string) (*models.ArtifactDownloadPlan, error)
 	DownloadByArtifactID(artifactID uint64, destination string) (*models.ArtifactDownloadPlan, error)
 	DownloadByCommitHash(commitHash string, lookup *models.ArtifactLookupOptions, destination string) (*models.ArtifactDownloadPlan, error)
 	DownloadByName(name string, lookup *models.ArtifactLookupOptions, destination string) (*models.ArtifactDownloadPlan, error)
+	OpenArtifactByID(ctx context.Context, artifactID uint64) (*ArtifactReader, error)
+	OpenArtifactByCommitHash(ctx context.Context, commitHash string, lookup *models.ArtifactLookupOptions) (*ArtifactReader, error)
+	OpenArtifactByName(ctx context.Context, name string, lookup *models.ArtifactLookupOptions) (*ArtifactReader, error)
+	ExecuteDownloadPlan(plan *models.ArtifactDownloadPlan) (*models.ArtifactDownloadPlan, error)
+	RefreshDownloadPlan(plan *models.ArtifactDownloadPlan) error
+	GenerateArtifactLockfile(reqs []models.ArtifactLockRequest) (*models.ArtifactLockfile, error)
+	VerifyArtifactLockfile(lock *models.ArtifactLockfile, baseDir string) (*models.ArtifactLockVerifyResult, error)
+	SyncArtifactLockfile(lock *models.ArtifactLockfile, baseDir string) (*models.ArtifactLockVerifyResult, error)
 	GetVersionMetadataByCommitHash(commitHash string, lookup *models.ArtifactLookupOptions) (*models.ArtifactVersionMetadataInfo, error)
+	FetchVersionMetadata(commitHash string, lookup *models.ArtifactLookupOptions, options *models.ArtifactVersionMetadataOptions) (*models.ArtifactVersionMetadataInfo, error)
 	GetChildArtifactHashesByCommitHash(commitHash string, lookup *models.ArtifactLookupOptions) (*models.ArtifactChildHashesInfo, error)
+	BuildArtifactSBOM(artifactID uint64) (*cdx.BOM, error)
+	ExportArtifactSBOM(artifactID uint64, format models.ArtifactSBOMFormat, w io.Writer) error
+	GenerateArtifactProvenance(artifactID uint64) (*models.InTotoStatement, error)
+	SignArtifactProvenance(artifactID uint64, key ed25519.PrivateKey, keyID string) (*models.DSSEEnvelope, error)
+	VerifyArtifactProvenance(envelope *models.DSSEEnvelope, publicKey ed25519.PublicKey, filePath string) (*models.ArtifactProvenanceVerification, error)
+	SignArtifactFile(artifactID uint64, filePath string, key ed25519.PrivateKey, keyID string) (*models.ArtifactSignature, error)
+	VerifyArtifactFileSignature(artifact *models.ArtifactInfo, filePath string) (*models.ArtifactSignature, error)
 	GetArtifactCommitDiff(artifactIDA, artifactIDB uint64) (*models.ArtifactCommitDiffInfo, error)
+	GetArtifactCommitDiffByCommitHash(commitHashA, commitHashB string, lookup *models.ArtifactLookupOptions) (*models.ArtifactCommitDiffInfo, error)
+	GetArtifactCommitDiffByVersion(name, versionA, versionB string, lookup *models.ArtifactLookupOptions) (*models.ArtifactCommitDiffInfo, error)
+	GetArtifactCommitDiffSeries(req *models.ArtifactCommitDiffSeriesReq) (*models.ArtifactCommitDiffSeries, error)
+	FindArtifactsContainingCommit(req *models.ArtifactCommitSearchReq) (*models.ArtifactCommitSearchResult, error)
+	CompareArtifacts(artifactIDA, artifactIDB uint64) (*models.ArtifactDiff, error)
+	BuildArtifactChangelog(artifactIDA, artifactIDB uint64, options *models.ArtifactChangelogOptions) (*models.ArtifactChangelog, error)
 	GetArtifactTagSchema(version string) (*models.ArtifactTagSchemaInfo, error)
 	GetArtifactTagSchemaJSON(version string) (map[string]any, error)
 	GetJfrogToken(projectName string) (*models.JfrogTokenInfo, error)
+	InvalidateJfrogToken(projectName string)
 	GetJfrogTokenByArtifactName(name string, lookup *models.ArtifactLookupOptions) (*models.JfrogTokenInfo, error)
 	GetArtifactDownloadURL(artifactID uint64, downloadType string) (*models.ArtifactDownloadURLInfo, error)
 	GetArtifactDownloadURLByName(name string, lookup *models.ArtifactLookupOptions, downloadType string) (*models.ArtifactDownloadURLInfo, error)
 	GetParsedArtifactTags(artifactID uint64) (map[string]any, error)
+	MergeArtifactExtra(artifactID uint64, patch map[string]any) (map[string]any, error)
 	UpdateArtifactTags(artifactID uint64, tags map[string]any, tagSchemaVersion string) (*models.BaseMsgResp, error)
+	PatchArtifactTags(artifactID uint64, req *models.ArtifactTagPatchReq) (*models.ArtifactTagPatchResult, error)
+	PromoteArtifact(req *models.ArtifactPromotionReq) (*models.ArtifactPromotionResult, error)
+	GetArtifactPromotionState(artifactID uint64, workflow *models.ArtifactPromotionWorkflow) (*models.ArtifactPromotionState, error)
 	ParseArtifactTags(tags string, schema any) (map[string]any, error)
+	GenerateArtifactTagTypes(version string, options *models.ArtifactTagCodegenOptions) ([]byte, error)
+	RegisterArtifactTagMigration(migration models.ArtifactTagMigration) error
+	UpgradeArtifactTags(tags map[string]any, targetVersion string) (map[string]any, error)
+	GetParsedArtifactTagsAtVersion(artifactID uint64, version string) (map[string]any, error)
+	MigrateArtifactTags(req *models.ArtifactTagMigrationReq) (*models.ArtifactTagMigrationReport, error)
 }
 
 type artifactService struct {
 	httpClient       *client.HTTPClient
 	downloadArtifact func(token *models.JfrogTokenInfo, filePath, targetDir string) error
+	searchJfrog      func(token *models.JfrogTokenInfo, pattern string) ([]models.JfrogFileInfo, error)
+	jfrogTokens      *jfrogTokenCache
+
+	tagMigrationsMu sync.RWMutex
+	tagMigrations   []models.ArtifactTagMigration
 }
 
 // NewArtifactService creates a new artifact service.
@@ -64,6 +123,8 @@ func NewArtifactService(httpClient *client.HTTPClient) ArtifactService {
 	return &artifactService{
 		httpClient:       httpClient,
 		downloadArtifact: downloadWithJFrog,
+		searchJfrog:      searchWithJFrog,
+		jfrogTokens:      newJfrogTokenCache(),
 	}
 }
 
@@ -135,26 +196,17 @@ func (s *artifactService) GetArtifactByID(id uint64) (*models.ArtifactInfo, erro
 	return &response.Data, nil
 }
 
+// GetArtifactByName returns the artifact with the given name. When several
+// match, it fails unless lookup.Selection picks one.
 func (s *artifactService) GetArtifactByName(name string, lookup *models.ArtifactLookupOptions) (*models.ArtifactInfo, error) {
-	req := &models.ArtifactListReq{Page: 1, PageSize: 100, Name: &name}
-	if lookup != nil {
-		if lookup.ModulePath != "" {
-			req.ModulePath = &lookup.ModulePath
-		}
-		if lookup.ArtifactType != "" {
-			req.Type = &lookup.ArtifactType
-		}
-		if lookup.Platform != nil {
-			req.Platform = lookup.Platform
-		}
-		if lookup.SemanticVersion != "" {
-			req.SemanticVersion = &lookup.SemanticVersion
-		}
-		if lookup.ProjectName != "" {
-			req.ProjectName = &lookup.ProjectName
+	if lookup != nil && lookup.Selection != nil {
+		selection, err := s.SelectArtifactByName(name, lookup, lookup.Selection)
+		if err != nil {
+			return nil, err
 		}
-		req.IsVirtual = lookup.IncludeVirtual
+		return selection.Selected, nil
 	}
+	req := buildNameLookupListRequest(name, lookup)
 	result, err := s.ListArtifacts(req)
 	if err != nil {
 		return nil, err
@@ -196,13 +248,12 @@ func (s *artifactService) GetArtifactByCommitHash(commitHash string, lookup *mod
 func (s *artifactService) CheckExistsByCommitHash(commitHash string, lookup *models.ArtifactLookupOptions) (bool, error) {
 	artifact, err := s.GetArtifactByCommitHash(commitHash, lookup)
 	if err != nil {
-		if strings.Contains(err.Error(), "artifact not found by commit hash") {
+		if isArtifactNotFoundByCommitHash(err) {
 			return false, nil
 		}
 		return false, err
 	}
-	return artifact.FullPath != nil && *artifact.FullPath != "" &&
-		artifact.FileHash != nil && *artifact.FileHash != "", nil
+	return artifactAvailable(artifact), nil
 }
 
 func (s *artifactService) CheckExistsByName(name string, lookup *models.ArtifactLookupOptions) (bool, error) {
@@ -262,14 +313,64 @@ func (s *artifactService) executeDownloadPlan(plan *models.ArtifactDownloadPlan)
 	}
 	if skipped {
 		plan.SkippedExisting = true
+		if err := s.verifyDownloadedSignature(plan, false); err != nil {
+			return nil, err
+		}
 		return plan, nil
 	}
-	if err := s.downloadArtifact(plan.Token, plan.DownloadURL.FilePath, targetDir); err != nil {
+	if IsDownloadPlanStale(plan, time.Now()) {
+		plan.Stale = true
+		if err := s.RefreshDownloadPlan(plan); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.downloadWithTokenRetry(plan, targetDir); err != nil {
+		return nil, err
+	}
+	if err := s.verifyDownloadedSignature(plan, true); err != nil {
 		return nil, err
 	}
 	return plan, nil
 }
 
+// downloadWithTokenRetry downloads the plan's file. When JFrog rejects the
+// token with 401/403, the cached token is dropped, the plan is refreshed and
+// the download is retried once.
+func (s *artifactService) downloadWithTokenRetry(plan *models.ArtifactDownloadPlan, targetDir string) error {
+	err := s.downloadArtifact(plan.Token, plan.DownloadURL.FilePath, targetDir)
+	if err == nil || !isJfrogAuthError(err) || plan.Artifact == nil || valueOrEmpty(plan.Artifact.ProjectName) == "" {
+		return err
+	}
+	projectName := *plan.Artifact.ProjectName
+	utils.Warn("JFrog rejected the token for project %s, refreshing: %v", projectName, err)
+	s.jfrogTokens.invalidate(projectName, plan.Token)
+	if err := s.RefreshDownloadPlan(plan); err != nil {
+		return err
+	}
+	return s.downloadArtifact(plan.Token, plan.DownloadURL.FilePath, targetDir)
+}
+
+func isJfrogAuthError(err error) bool {
+	var sdkErr *utils.SDKError
+	return errors.As(err, &sdkErr) && (sdkErr.Code == utils.ErrCodeUnauthorized || sdkErr.Code == utils.ErrCodeForbidden)
+}
+
+// verifyDownloadedSignature enforces signatures when trusted keys are
+// configured. Freshly downloaded files that fail verification are removed.
+func (s *artifactService) verifyDownloadedSignature(plan *models.ArtifactDownloadPlan, downloaded bool) error {
+	keys := s.httpClient.ArtifactTrustedKeys()
+	if len(keys) == 0 {
+		return nil
+	}
+	if _, err := verifyArtifactSignature(plan.Artifact, plan.TargetPath, keys); err != nil {
+		if downloaded {
+			_ = os.Remove(plan.TargetPath)
+		}
+		return err
+	}
+	return nil
+}
+
 func (s *artifactService) DownloadByName(name string, lookup *models.ArtifactLookupOptions, destination string) (*models.ArtifactDownloadPlan, error) {
 	artifact, err := s.GetArtifactByName(name, lookup)
 	if err != nil {
@@ -316,26 +417,7 @@ func (s *artifactService) prepareDownload(artifact *models.ArtifactInfo, destina
 }
 
 func (s *artifactService) GetVersionMetadataByCommitHash(commitHash string, lookup *models.ArtifactLookupOptions) (*models.ArtifactVersionMetadataInfo, error) {
-	var response struct {
-		Code int                                `json:"code"`
-		Msg  string                             `json:"msg"`
-		Data models.ArtifactVersionMetadataInfo `json:"data"`
-	}
-	req := buildCommitHashLookupRequest(commitHash, lookup)
-	if err := s.httpClient.Post("/aiplorer/artifact/version-metadata", req, &response); err != nil {
-		return nil, utils.NewAPIError("failed to get artifact version metadata", err)
-	}
-	if response.Code != 0 {
-		return nil, utils.NewAPIError(response.Msg, nil)
-	}
-	if response.Data.RawContent != nil && *response.Data.RawContent != "" {
-		parsed, err := parseVersionMetadata(*response.Data.RawContent, valueOrEmpty(response.Data.MetadataFileName))
-		if err != nil {
-			return nil, err
-		}
-		response.Data.Parsed = parsed
-	}
-	return &response.Data, nil
+	return s.FetchVersionMetadata(commitHash, lookup, nil)
 }
 
 func (s *artifactService) GetChildArtifactHashesByCommitHash(commitHash string, lookup *models.ArtifactLookupOptions) (*models.ArtifactChildHashesInfo, error) {
@@ -409,7 +491,22 @@ func (s *artifactService) GetArtifactTagSchemaJSON(version string) (map[string]a
 	return models.ParseJSON(schema.Content)
 }
 
+// GetJfrogToken returns a JFrog token for the project, reusing a cached one
+// until shortly before it expires.
 func (s *artifactService) GetJfrogToken(projectName string) (*models.JfrogTokenInfo, error) {
+	return s.jfrogTokens.get(projectName, func() (*models.JfrogTokenInfo, error) {
+		return s.fetchJfrogToken(projectName)
+	})
+}
+
+// InvalidateJfrogToken drops the cached token for the project, for example
+// after JFrog rejected it.
+func (s *artifactService) InvalidateJfrogToken(projectName string) {
+	s.jfrogTokens.invalidate(projectName, nil)
+}
+
+func (s *artifactService) fetchJfrogToken(projectName string) (*models.JfrogTokenInfo, error) {
+	utils.Debug("Requesting jfrog token for project: %s", projectName)
 	var response struct {
 		Code int                   `json:"code"`
 		Msg  string                `json:"msg"`
@@ -421,6 +518,9 @@ func (s *artifactService) GetJfrogToken(projectName string) (*models.JfrogTokenI
 	if response.Code != 0 {
 		return nil, utils.NewAPIError(response.Msg, nil)
 	}
+	if response.Data.ExpiresIn > 0 && response.Data.ExpiresAt == 0 {
+		response.Data.ExpiresAt = time.Now().Unix() + response.Data.ExpiresIn
+	}
 	return &response.Data, nil
 }
 
@@ -453,6 +553,11 @@ func (s *artifactService) GetArtifactDownloadURL(artifactID uint64, downloadType
 	if response.Code != 0 {
 		return nil, utils.NewAPIError(response.Msg, nil)
 	}
+	if expiresAt, err := parseDownloadURLExpireTime(response.Data.ExpireTime); err != nil {
+		utils.Warn("Ignoring unparseable download url expireTime %q: %v", response.Data.ExpireTime, err)
+	} else {
+		response.Data.ExpiresAt = expiresAt
+	}
 	return &response.Data, nil
 }
 
@@ -541,7 +646,10 @@ func (s *artifactService) ParseArtifactTags(tags string, schema any) (map[string
 	schemaVersion, hasSchemaVersion := parsedSchema["version"].(string)
 	tagSchemaVersion, hasTagSchemaVersion := parsedTags["schema_version"].(string)
 	if hasSchemaVersion && hasTagSchemaVersion && schemaVersion != tagSchemaVersion {
-		return nil, utils.NewAPIError("artifact tag schema version does not match tag schema_version", nil)
+		if !s.hasArtifactTagMigrationPath(tagSchemaVersion, schemaVersion) {
+			return nil, utils.NewAPIError("artifact tag schema version does not match tag schema_version", nil)
+		}
+		return s.UpgradeArtifactTags(parsedTags, schemaVersion)
 	}
 	return parsedTags, nil
 }
@@ -561,6 +669,73 @@ func (s *artifactService) batchCheckArtifactsExist(req *models.BatchCheckArtifac
 	return &response.Data, nil
 }
 
+// forEachArtifact pages through ListArtifacts with the given filter and calls
+// fn for every item until fn returns false or the listing is exhausted.
+func (s *artifactService) forEachArtifact(filter *models.ArtifactListReq, fn func(item models.ArtifactInfo) (bool, error)) error {
+	req := models.ArtifactListReq{}
+	if filter != nil {
+		req = *filter
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = defaultListPageSize
+	}
+	for seen := uint64(0); ; req.Page++ {
+		result, err := s.ListArtifacts(&req)
+		if err != nil {
+			return err
+		}
+		for _, item := range result.Data {
+			next, err := fn(item)
+			if err != nil {
+				return err
+			}
+			if !next {
+				return nil
+			}
+		}
+		seen += uint64(len(result.Data))
+		if len(result.Data) == 0 {
+			return nil
+		}
+		if result.Total > 0 && seen >= result.Total {
+			return nil
+		}
+		if result.Total == 0 && uint64(len(result.Data)) < req.PageSize {
+			return nil
+		}
+	}
+}
+
+func buildNameLookupListRequest(name string, lookup *models.ArtifactLookupOptions) *models.ArtifactListReq {
+	req := &models.ArtifactListReq{Page: 1, PageSize: defaultListPageSize}
+	if name != "" {
+		req.Name = &name
+	}
+	if lookup == nil {
+		return req
+	}
+	if lookup.ModulePath != "" {
+		req.ModulePath = &lookup.ModulePath
+	}
+	if lookup.ArtifactType != "" {
+		req.Type = &lookup.ArtifactType
+	}
+	if lookup.Platform != nil {
+		req.Platform = lookup.Platform
+	}
+	if lookup.SemanticVersion != "" {
+		req.SemanticVersion = &lookup.SemanticVersion
+	}
+	if lookup.ProjectName != "" {
+		req.ProjectName = &lookup.ProjectName
+	}
+	req.IsVirtual = lookup.IncludeVirtual
+	return req
+}
+
 func buildCommitHashLookupRequest(commitHash string, lookup *models.ArtifactLookupOptions) *models.GetArtifactByCommitHashReq {
 	req := &models.GetArtifactByCommitHashReq{CommitHash: commitHash}
 	if lookup == nil {
@@ -611,7 +786,7 @@ func valueOrEmpty(value *string) string {
 	return *value
 }
 
-func downloadWithJFrog(token *models.JfrogTokenInfo, filePath, targetDir string) error {
+func newJfrogManager(token *models.JfrogTokenInfo) (jfrogartifactory.ArtifactoryServicesManager, error) {
 	rtDetails := jfrogAuth.NewArtifactoryDetails()
 	baseURL := strings.TrimRight(token.URL, "/")
 	if !strings.HasSuffix(baseURL, "/artifactory") {
@@ -622,12 +797,20 @@ func downloadWithJFrog(token *models.JfrogTokenInfo, filePath, targetDir string)
 
 	serviceConfig, err := jfrogConfig.NewConfigBuilder().SetServiceDetails(rtDetails).Build()
 	if err != nil {
-		return utils.NewInternalError("failed to build jfrog service config", err)
+		return nil, utils.NewInternalError("failed to build jfrog service config", err)
 	}
 
 	manager, err := jfrogartifactory.New(serviceConfig)
 	if err != nil {
-		return utils.NewInternalError("failed to create jfrog client", err)
+		return nil, utils.NewInternalError("failed to create jfrog client", err)
+	}
+	return manager, nil
+}
+
+func downloadWithJFrog(token *models.JfrogTokenInfo, filePath, targetDir string) error {
+	manager, err := newJfrogManager(token)
+	if err != nil {
+		return err
 	}
 
 	params := jfrogServices.NewDownloadParams()
@@ -640,7 +823,7 @@ func downloadWithJFrog(token *models.JfrogTokenInfo, filePath, targetDir string)
 
 	downloaded, failed, err := manager.DownloadFiles(params)
 	if err != nil {
-		return utils.NewInternalError("failed to download artifact with jfrog client", err)
+		return jfrogRequestError(err, "failed to download artifact with jfrog client")
 	}
 	if downloaded == 0 || failed > 0 {
 		return utils.NewInternalError("jfrog download did not complete successfully", nil)
@@ -648,20 +831,16 @@ func downloadWithJFrog(token *models.JfrogTokenInfo, filePath, targetDir string)
 	return nil
 }
 
-func parseVersionMetadata(rawContent, fileName string) (map[string]any, error) {
-	lowerName := strings.ToLower(fileName)
-	if strings.HasSuffix(lowerName, ".json") {
-		return models.ParseJSON(rawContent)
+// jfrogRequestError maps JFrog 401/403 responses to auth errors so callers
+// can refresh the token.
+func jfrogRequestError(err error, message string) error {
+	switch {
+	case strings.Contains(err.Error(), "server response: 401"):
+		return utils.NewUnauthorizedError("jfrog rejected the access token", err)
+	case strings.Contains(err.Error(), "server response: 403"):
+		return utils.NewForbiddenError("jfrog denied access to the artifact", err)
 	}
-	if strings.HasSuffix(lowerName, ".xml") {
-		return parseXMLMetadata(rawContent)
-	}
-
-	parsed, err := models.ParseJSON(rawContent)
-	if err == nil {
-		return parsed, nil
-	}
-	return parseXMLMetadata(rawContent)
+	return utils.NewInternalError(message, err)
 }
 
 type xmlNode struct {
@@ -738,38 +917,67 @@ func skipDownloadIfExisting(targetPath, checksum string) (bool, error) {
 }
 
 func verifyFileHash(targetPath, expected string) (bool, error) {
-	file, err := os.Open(targetPath)
+	actual, err := fileHashHex(targetPath, expected)
 	if err != nil {
-		return false, utils.NewInternalError("failed to open existing artifact file", err)
+		return false, err
 	}
-	defer file.Close()
+	return strings.EqualFold(actual, strings.TrimSpace(expected)), nil
+}
 
+// fileHashHex hashes a file with the algorithm implied by the expected checksum.
+func fileHashHex(targetPath, expected string) (string, error) {
 	hasher, err := newHasher(expected)
 	if err != nil {
-		return false, err
+		return "", err
 	}
+	return hashFile(targetPath, hasher)
+}
+
+func hashFile(targetPath string, hasher hashWriter) (string, error) {
+	file, err := os.Open(targetPath)
+	if err != nil {
+		return "", utils.NewInternalError("failed to open existing artifact file", err)
+	}
+	defer file.Close()
+
 	if _, err := io.Copy(hasher, file); err != nil {
-		return false, utils.NewInternalError("failed to hash existing artifact file", err)
+		return "", utils.NewInternalError("failed to hash existing artifact file", err)
 	}
-	actual := fmt.Sprintf("%x", hasher.Sum(nil))
-	return strings.EqualFold(actual, strings.TrimSpace(expected)), nil
+	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
 }
 
 func newHasher(expected string) (hashWriter, error) {
-	switch len(strings.TrimSpace(expected)) {
-	case 32:
+	switch fileHashAlgorithm(expected) {
+	case "md5":
 		return md5.New(), nil
-	case 40:
+	case "sha1":
 		return sha1.New(), nil
-	case 64:
+	case "sha256":
 		return sha256.New(), nil
-	case 128:
+	case "sha512":
 		return sha512.New(), nil
 	default:
 		return nil, utils.NewInvalidInputError("unsupported artifact checksum length", nil)
 	}
 }
 
+// fileHashAlgorithm names the algorithm of a hex file hash by its length:
+// md5, sha1, sha256 or sha512, or "" when the length matches none of them.
+func fileHashAlgorithm(fileHash string) string {
+	switch len(strings.TrimSpace(fileHash)) {
+	case 32:
+		return "md5"
+	case 40:
+		return "sha1"
+	case 64:
+		return "sha256"
+	case 128:
+		return "sha512"
+	default:
+		return ""
+	}
+}
+
 type hashWriter interface {
 	io.Writer
 	Sum(b []byte) []byte
@@ -780,6 +988,14 @@ func mustJSON(v any) string {
 	return string(buf)
 }
 
+func remarshalJSON(in any, out any) error {
+	buf, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, out)
+}
+
 func stringPtr(v string) *string {
 	return &v
 }
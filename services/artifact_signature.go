@@ -0,0 +1,135 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+const (
+	artifactSignatureAlgorithm   = "ed25519"
+	artifactSignaturePayloadType = "application/vnd.intranet.artifact-digest"
+)
+
+// SignArtifactFile signs the digest of a local artifact file and stores the
+// detached signature in the artifact's Extra metadata.
+func (s *artifactService) SignArtifactFile(artifactID uint64, filePath string, key ed25519.PrivateKey, keyID string) (*models.ArtifactSignature, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, utils.NewInvalidInputError("invalid ed25519 private key", nil)
+	}
+	if strings.TrimSpace(keyID) == "" {
+		return nil, utils.NewInvalidInputError("signing key id is required", nil)
+	}
+	artifact, err := s.GetArtifactByID(artifactID)
+	if err != nil {
+		return nil, err
+	}
+	if expected := valueOrEmpty(artifact.FileHash); expected != "" {
+		matched, err := verifyFileHash(filePath, expected)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			return nil, utils.NewInvalidInputError(fmt.Sprintf("file %s does not match the file hash of artifact %d", filePath, artifactID), nil)
+		}
+	}
+
+	digest, err := hashFile(filePath, sha256.New())
+	if err != nil {
+		return nil, err
+	}
+	signature := &models.ArtifactSignature{
+		Algorithm:       artifactSignatureAlgorithm,
+		KeyID:           keyID,
+		DigestAlgorithm: "sha256",
+		Digest:          digest,
+		SignedAt:        time.Now().Unix(),
+	}
+	signature.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, artifactSignatureMessage(signature)))
+
+	if _, err := s.MergeArtifactExtra(artifactID, map[string]any{models.ArtifactSignatureExtraKey: signature}); err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// VerifyArtifactFileSignature checks a local file against the signature stored
+// in the artifact's Extra metadata using the configured trusted keys.
+func (s *artifactService) VerifyArtifactFileSignature(artifact *models.ArtifactInfo, filePath string) (*models.ArtifactSignature, error) {
+	keys := s.httpClient.ArtifactTrustedKeys()
+	if len(keys) == 0 {
+		return nil, utils.NewInvalidInputError("no trusted artifact signing keys configured", nil)
+	}
+	return verifyArtifactSignature(artifact, filePath, keys)
+}
+
+func verifyArtifactSignature(artifact *models.ArtifactInfo, filePath string, keys map[string]ed25519.PublicKey) (*models.ArtifactSignature, error) {
+	signature, err := checkArtifactSignature(artifact, keys)
+	if err != nil {
+		return nil, err
+	}
+	matched, err := verifyFileHash(filePath, signature.Digest)
+	if err != nil {
+		return nil, err
+	}
+	if !matched {
+		return nil, utils.NewSignatureError(fmt.Sprintf("file %s does not match the signed digest of artifact %d", filePath, *artifact.ID), nil)
+	}
+	return signature, nil
+}
+
+// checkArtifactSignature verifies the signature stored in the artifact's
+// Extra metadata against the trusted keys. The caller still has to compare
+// the content with the signed digest.
+func checkArtifactSignature(artifact *models.ArtifactInfo, keys map[string]ed25519.PublicKey) (*models.ArtifactSignature, error) {
+	if artifact == nil || artifact.ID == nil {
+		return nil, utils.NewInvalidInputError("artifact is required for signature verification", nil)
+	}
+	signature, err := artifactSignatureFromExtra(artifact)
+	if err != nil {
+		return nil, err
+	}
+	if signature == nil {
+		return nil, utils.NewSignatureError(fmt.Sprintf("artifact %d is not signed", *artifact.ID), nil)
+	}
+	if signature.Algorithm != artifactSignatureAlgorithm || signature.DigestAlgorithm != "sha256" {
+		return nil, utils.NewSignatureError(fmt.Sprintf("artifact %d has unsupported signature %s/%s", *artifact.ID, signature.Algorithm, signature.DigestAlgorithm), nil)
+	}
+	publicKey, ok := keys[signature.KeyID]
+	if !ok {
+		return nil, utils.NewSignatureError(fmt.Sprintf("artifact %d is signed by untrusted key %q", *artifact.ID, signature.KeyID), nil)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature.Signature)
+	if err != nil || !ed25519.Verify(publicKey, artifactSignatureMessage(signature), sig) {
+		return nil, utils.NewSignatureError(fmt.Sprintf("artifact %d has an invalid signature", *artifact.ID), err)
+	}
+	return signature, nil
+}
+
+func artifactSignatureFromExtra(artifact *models.ArtifactInfo) (*models.ArtifactSignature, error) {
+	extra, err := artifactExtraMap(artifact)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := extra[models.ArtifactSignatureExtraKey]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	var signature models.ArtifactSignature
+	if err := remarshalJSON(value, &signature); err != nil {
+		return nil, utils.NewSignatureError(fmt.Sprintf("artifact %d has a malformed signature", *artifact.ID), err)
+	}
+	return &signature, nil
+}
+
+// artifactSignatureMessage binds the digest and its algorithm into the signed bytes.
+func artifactSignatureMessage(signature *models.ArtifactSignature) []byte {
+	payload := signature.DigestAlgorithm + ":" + strings.ToLower(signature.Digest)
+	return dssePAE(artifactSignaturePayloadType, []byte(payload))
+}
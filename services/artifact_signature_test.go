@@ -0,0 +1,91 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/client"
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+func TestSignArtifactFileAndVerifyOnDownload(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	extra := `{"owner":"ci"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":5,"updatedAt":100,"name":"vision","projectName":"proj","fileHash":"` + helloMD5 + `","extra":` + mustJSON(extra) + `}}`))
+		case "/aiplorer/artifact/update":
+			payload := decodeBody(t, r)
+			extra = payload["extra"].(string)
+			_, _ = w.Write([]byte(`{"code":0,"msg":"updated"}`))
+		case "/aiplorer/jfrog/token":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"accessToken":"token","url":"http://jfrog"}}`))
+		case "/aiplorer/artifact/download-url":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"fileName":"vision.zip","filePath":"repo/vision.zip"}}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	newService := func(keys map[string]ed25519.PublicKey) *artifactService {
+		httpClient, err := client.NewHTTPClient(&client.Config{BaseURL: server.URL, ArtifactTrustedKeys: keys})
+		if err != nil {
+			t.Fatalf("new http client: %v", err)
+		}
+		service := NewArtifactService(httpClient).(*artifactService)
+		service.downloadArtifact = func(token *models.JfrogTokenInfo, filePath, targetDir string) error {
+			return os.WriteFile(filepath.Join(targetDir, "vision.zip"), []byte("hello"), 0o644)
+		}
+		return service
+	}
+	service := newService(map[string]ed25519.PublicKey{"release": publicKey})
+
+	_, err = service.DownloadByArtifactID(5, t.TempDir()+"/")
+	var sdkErr *utils.SDKError
+	if !errors.As(err, &sdkErr) || sdkErr.Code != utils.ErrCodeSignatureInvalid {
+		t.Fatalf("expected unsigned artifact to be refused, got %v", err)
+	}
+
+	source := filepath.Join(t.TempDir(), "vision.zip")
+	if err := os.WriteFile(source, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	signature, err := service.SignArtifactFile(5, source, privateKey, "release")
+	if err != nil {
+		t.Fatalf("SignArtifactFile error: %v", err)
+	}
+	if signature.KeyID != "release" || !strings.Contains(extra, `"owner":"ci"`) || !strings.Contains(extra, signature.Signature) {
+		t.Fatalf("unexpected extra after signing: %s", extra)
+	}
+
+	plan, err := service.DownloadByArtifactID(5, t.TempDir()+"/")
+	if err != nil {
+		t.Fatalf("expected signed artifact to download: %v", err)
+	}
+	if _, err := os.Stat(plan.TargetPath); err != nil {
+		t.Fatalf("expected downloaded file: %v", err)
+	}
+
+	otherKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	_, err = newService(map[string]ed25519.PublicKey{"release": otherKey}).DownloadByArtifactID(5, t.TempDir()+"/")
+	if !errors.As(err, &sdkErr) || sdkErr.Code != utils.ErrCodeSignatureInvalid {
+		t.Fatalf("expected mis-signed artifact to be refused, got %v", err)
+	}
+
+	if _, err := newService(nil).DownloadByArtifactID(5, t.TempDir()+"/"); err != nil {
+		t.Fatalf("downloads without trusted keys must not verify signatures: %v", err)
+	}
+}
@@ -0,0 +1,413 @@
+package services
+
+import (
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+func (s *artifactService) GenerateArtifactTagTypes(version string, options *models.ArtifactTagCodegenOptions) ([]byte, error) {
+	schema, err := s.GetArtifactTagSchema(version)
+	if err != nil {
+		return nil, err
+	}
+	parsedSchema, err := models.ParseJSON(schema.Content)
+	if err != nil {
+		return nil, utils.NewAPIError("failed to decode artifact tag schema", err)
+	}
+	if schema.Version != "" {
+		if _, ok := parsedSchema["version"]; !ok {
+			parsedSchema["version"] = schema.Version
+		}
+	}
+	return generateArtifactTagTypes(parsedSchema, options)
+}
+
+// DecodeArtifactTags converts parsed tags into a caller-supplied struct and
+// runs its Validate method when one is defined.
+func DecodeArtifactTags[T any](tags map[string]any) (*T, error) {
+	var typed T
+	if err := remarshalJSON(tags, &typed); err != nil {
+		return nil, utils.NewAPIError("failed to decode artifact tags", err)
+	}
+	if err := validateTyped(&typed); err != nil {
+		return nil, utils.NewInvalidInputError("artifact tags failed validation", err)
+	}
+	return &typed, nil
+}
+
+// GetArtifactTagsAs loads and schema-checks an artifact's tags, then decodes them into T.
+func GetArtifactTagsAs[T any](service ArtifactService, artifactID uint64) (*T, error) {
+	tags, err := service.GetParsedArtifactTags(artifactID)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeArtifactTags[T](tags)
+}
+
+// UpdateArtifactTagsFrom validates typed tags and writes them with UpdateArtifactTags.
+func UpdateArtifactTagsFrom[T any](service ArtifactService, artifactID uint64, tags *T, tagSchemaVersion string) (*models.BaseMsgResp, error) {
+	if tags == nil {
+		return nil, utils.NewInvalidInputError("artifact tags are nil", nil)
+	}
+	if err := validateTyped(tags); err != nil {
+		return nil, utils.NewInvalidInputError("artifact tags failed validation", err)
+	}
+	var raw map[string]any
+	if err := remarshalJSON(tags, &raw); err != nil {
+		return nil, utils.NewInternalError("failed to encode artifact tags", err)
+	}
+	return service.UpdateArtifactTags(artifactID, raw, tagSchemaVersion)
+}
+
+func validateTyped(value any) error {
+	if validator, ok := value.(interface{ Validate() error }); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+type tagCodegen struct {
+	root    map[string]any
+	structs []*tagCodegenStruct
+	names   map[string]bool
+	// refs maps each $ref target to the struct generated for it, so repeated
+	// and recursive references share one type.
+	refs     map[string]string
+	building map[string]bool
+	usesFmt  bool
+}
+
+type tagCodegenStruct struct {
+	name        string
+	description string
+	fields      []tagCodegenField
+}
+
+type tagCodegenField struct {
+	name        string
+	jsonName    string
+	goType      string
+	description string
+	required    bool
+	enum        []string
+	structName  string
+	isSlice     bool
+	nilable     bool
+	pointer     bool
+}
+
+func generateArtifactTagTypes(schema map[string]any, options *models.ArtifactTagCodegenOptions) ([]byte, error) {
+	packageName := "tags"
+	typeName := "ArtifactTags"
+	if options != nil {
+		if options.PackageName != "" {
+			packageName = options.PackageName
+		}
+		if options.TypeName != "" {
+			typeName = options.TypeName
+		}
+	}
+
+	gen := &tagCodegen{root: schema, names: map[string]bool{}, refs: map[string]string{}, building: map[string]bool{}}
+	if _, err := gen.buildStruct(typeName, "#", schema); err != nil {
+		return nil, utils.NewInvalidInputError("failed to generate artifact tag types", err)
+	}
+
+	var body strings.Builder
+	if version, ok := schema["version"].(string); ok && version != "" {
+		fmt.Fprintf(&body, "// %sSchemaVersion is the tag schema version these types were generated from.\n", typeName)
+		fmt.Fprintf(&body, "const %sSchemaVersion = %s\n\n", typeName, strconv.Quote(version))
+	}
+	for _, item := range gen.structs {
+		gen.writeStruct(&body, item)
+	}
+
+	var out strings.Builder
+	out.WriteString("// Code generated by artifact-tag-codegen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", packageName)
+	if gen.usesFmt {
+		out.WriteString("import \"fmt\"\n\n")
+	}
+	out.WriteString(body.String())
+
+	formatted, err := format.Source([]byte(out.String()))
+	if err != nil {
+		return nil, utils.NewInternalError("failed to format generated artifact tag types", err)
+	}
+	return formatted, nil
+}
+
+// buildStruct generates a struct for schema. ref is the $ref target the
+// schema was reached through, if any; it is registered before the properties
+// are visited so references back to it resolve to the struct being built.
+func (g *tagCodegen) buildStruct(name, ref string, schema map[string]any) (string, error) {
+	name = uniqueIdentifier(g.names, name)
+	if ref != "" {
+		g.refs[ref] = name
+	}
+	g.building[name] = true
+	defer delete(g.building, name)
+	item := &tagCodegenStruct{name: name}
+	item.description, _ = schema["description"].(string)
+	g.structs = append(g.structs, item)
+
+	properties, _ := schema["properties"].(map[string]any)
+	required := map[string]bool{}
+	if rawRequired, ok := schema["required"].([]any); ok {
+		for _, key := range rawRequired {
+			if text, ok := key.(string); ok {
+				required[text] = true
+			}
+		}
+	}
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Validate is the generated method name, so no field may take it.
+	fieldNames := map[string]bool{"Validate": true}
+	for _, key := range keys {
+		fieldSchema, ok := properties[key].(map[string]any)
+		if !ok {
+			fieldSchema = map[string]any{}
+		}
+		field := tagCodegenField{
+			name:     uniqueIdentifier(fieldNames, goIdentifier(key)),
+			jsonName: key,
+			required: required[key],
+		}
+		field.description, _ = fieldSchema["description"].(string)
+		goType, structName, isSlice, nullable, err := g.goType(fieldSchema, name+goIdentifier(key))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", key, err)
+		}
+		field.goType = goType
+		field.structName = structName
+		field.isSlice = isSlice
+		field.nilable = isSlice || strings.HasPrefix(goType, "map[") || goType == "any"
+		// A struct embedding one that is still being built by value would be
+		// an invalid recursive type.
+		field.pointer = !field.nilable && (!field.required || nullable || g.building[structName])
+		if field.pointer {
+			field.goType = "*" + goType
+		}
+		if rawEnum, ok := resolveCodegenSchema(fieldSchema, g.root)["enum"].([]any); ok && goType == "string" {
+			for _, value := range rawEnum {
+				if text, ok := value.(string); ok {
+					field.enum = append(field.enum, text)
+				}
+			}
+		}
+		item.fields = append(item.fields, field)
+	}
+	return name, nil
+}
+
+func (g *tagCodegen) goType(schema map[string]any, nameHint string) (string, string, bool, bool, error) {
+	ref, _ := schema["$ref"].(string)
+	if ref != "" {
+		resolved, err := resolveSchemaRef(ref, g.root)
+		if err != nil {
+			return "", "", false, false, err
+		}
+		schema = resolved
+		if ref != "#" {
+			nameHint = goIdentifier(ref[strings.LastIndex(ref, "/")+1:])
+		}
+	}
+
+	typeName, nullable := schemaTypeName(schema)
+	switch typeName {
+	case "object":
+		if _, ok := schema["properties"].(map[string]any); ok {
+			if structName, ok := g.refs[ref]; ok && ref != "" {
+				return structName, structName, false, nullable, nil
+			}
+			structName, err := g.buildStruct(nameHint, ref, schema)
+			return structName, structName, false, nullable, err
+		}
+		if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+			valueType, _, _, _, err := g.goType(additional, nameHint+"Value")
+			return "map[string]" + valueType, "", false, nullable, err
+		}
+		return "map[string]any", "", false, nullable, nil
+	case "array":
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			return "[]any", "", true, nullable, nil
+		}
+		elemType, structName, _, _, err := g.goType(items, nameHint+"Item")
+		return "[]" + elemType, structName, true, nullable, err
+	case "string":
+		return "string", "", false, nullable, nil
+	case "integer":
+		return "int64", "", false, nullable, nil
+	case "number":
+		return "float64", "", false, nullable, nil
+	case "boolean":
+		return "bool", "", false, nullable, nil
+	default:
+		return "any", "", false, nullable, nil
+	}
+}
+
+func (g *tagCodegen) writeStruct(out *strings.Builder, item *tagCodegenStruct) {
+	description := item.description
+	if description == "" {
+		description = "is generated from the artifact tag schema."
+	}
+	fmt.Fprintf(out, "// %s %s\n", item.name, strings.TrimSpace(description))
+	fmt.Fprintf(out, "type %s struct {\n", item.name)
+	for _, field := range item.fields {
+		if field.description != "" {
+			fmt.Fprintf(out, "\t// %s\n", strings.ReplaceAll(strings.TrimSpace(field.description), "\n", "\n\t// "))
+		}
+		tag := field.jsonName
+		if !field.required {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(out, "\t%s %s `json:%s`\n", field.name, field.goType, strconv.Quote(tag))
+	}
+	out.WriteString("}\n\n")
+
+	fmt.Fprintf(out, "// Validate checks required fields, enum values and nested objects.\n")
+	fmt.Fprintf(out, "func (v *%s) Validate() error {\n", item.name)
+	for _, field := range item.fields {
+		g.writeFieldValidation(out, field)
+	}
+	out.WriteString("\treturn nil\n}\n\n")
+}
+
+func (g *tagCodegen) writeFieldValidation(out *strings.Builder, field tagCodegenField) {
+	ref := "v." + field.name
+	if field.required && field.nilable {
+		g.usesFmt = true
+		fmt.Fprintf(out, "\tif %s == nil {\n\t\treturn fmt.Errorf(\"missing required field %%q\", %s)\n\t}\n", ref, strconv.Quote(field.jsonName))
+	}
+	if len(field.enum) > 0 {
+		g.usesFmt = true
+		value := ref
+		if field.pointer {
+			fmt.Fprintf(out, "\tif %s != nil {\n", ref)
+			value = "*" + ref
+		}
+		quoted := make([]string, 0, len(field.enum))
+		for _, item := range field.enum {
+			quoted = append(quoted, strconv.Quote(item))
+		}
+		fmt.Fprintf(out, "\tswitch %s {\n\tcase %s:\n\tdefault:\n", value, strings.Join(quoted, ", "))
+		fmt.Fprintf(out, "\t\treturn fmt.Errorf(\"%s: unexpected value %%q\", %s)\n\t}\n", field.jsonName, value)
+		if field.pointer {
+			out.WriteString("\t}\n")
+		}
+	}
+	if field.structName == "" {
+		return
+	}
+	g.usesFmt = true
+	switch {
+	case field.isSlice:
+		fmt.Fprintf(out, "\tfor i := range %s {\n\t\tif err := %s[i].Validate(); err != nil {\n", ref, ref)
+		fmt.Fprintf(out, "\t\t\treturn fmt.Errorf(\"%s[%%d]: %%w\", i, err)\n\t\t}\n\t}\n", field.jsonName)
+	case field.pointer:
+		fmt.Fprintf(out, "\tif %s != nil {\n\t\tif err := %s.Validate(); err != nil {\n", ref, ref)
+		fmt.Fprintf(out, "\t\t\treturn fmt.Errorf(\"%s: %%w\", err)\n\t\t}\n\t}\n", field.jsonName)
+	default:
+		fmt.Fprintf(out, "\tif err := %s.Validate(); err != nil {\n", ref)
+		fmt.Fprintf(out, "\t\treturn fmt.Errorf(\"%s: %%w\", err)\n\t}\n", field.jsonName)
+	}
+}
+
+// uniqueIdentifier returns name, or name with the first free numeric suffix
+// when used already holds it, and records the result in used.
+func uniqueIdentifier(used map[string]bool, name string) string {
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+func resolveCodegenSchema(schema map[string]any, root map[string]any) map[string]any {
+	if ref, ok := schema["$ref"].(string); ok {
+		if resolved, err := resolveSchemaRef(ref, root); err == nil {
+			return resolved
+		}
+	}
+	return schema
+}
+
+func schemaTypeName(schema map[string]any) (string, bool) {
+	switch typed := schema["type"].(type) {
+	case string:
+		return typed, false
+	case []any:
+		name := ""
+		nullable := false
+		for _, candidate := range typed {
+			text, _ := candidate.(string)
+			if text == "null" {
+				nullable = true
+				continue
+			}
+			if name == "" {
+				name = text
+			} else {
+				name = "any"
+			}
+		}
+		return name, nullable
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object", false
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		for _, value := range enum {
+			if _, ok := value.(string); !ok {
+				return "any", false
+			}
+		}
+		return "string", false
+	}
+	return "any", false
+}
+
+var goInitialisms = map[string]string{
+	"id": "ID", "url": "URL", "uri": "URI", "api": "API", "http": "HTTP",
+	"json": "JSON", "sha": "SHA", "md5": "MD5", "cpu": "CPU", "gpu": "GPU",
+	"sdk": "SDK", "ci": "CI", "os": "OS",
+}
+
+func goIdentifier(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var out strings.Builder
+	for _, part := range parts {
+		if initialism, ok := goInitialisms[strings.ToLower(part)]; ok {
+			out.WriteString(initialism)
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		out.WriteString(string(runes))
+	}
+	result := out.String()
+	if result == "" {
+		return "Field"
+	}
+	if unicode.IsDigit([]rune(result)[0]) {
+		return "F" + result
+	}
+	return result
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"errors"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func TestGenerateArtifactTagTypes(t *testing.T) {
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/aiplorer/artifact/tag-schema" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		payload := decodeBody(t, r)
+		if payload["version"].(string) != "0.2.0" {
+			t.Fatalf("unexpected schema payload: %#v", payload)
+		}
+		_, _ = w.Write([]byte(`{"code":0,"data":{"version":"0.2.0","content":` + mustJSON(testTagSchema) + `}}`))
+	})
+
+	source, err := service.GenerateArtifactTagTypes("0.2.0", &models.ArtifactTagCodegenOptions{PackageName: "tagtypes"})
+	if err != nil {
+		t.Fatalf("GenerateArtifactTagTypes error: %v", err)
+	}
+	typeCheckGeneratedTags(t, source)
+	for _, want := range []string{
+		"package tagtypes",
+		`const ArtifactTagsSchemaVersion = "0.2.0"`,
+		"Decision      string                     `json:\"decision\"`",
+		"DecisionBasis *ArtifactTagsDecisionBasis `json:\"decision_basis,omitempty\"`",
+		`case "pass", "fail", "pending":`,
+		"func (v *ArtifactTagsDecisionBasis) Validate() error",
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Fatalf("generated source missing %q:\n%s", want, source)
+		}
+	}
+}
+
+func TestGenerateArtifactTagTypesSharesReferencedStructs(t *testing.T) {
+	schema, err := models.ParseJSON(`{
+		"version": "1.0.0",
+		"type": "object",
+		"required": ["root"],
+		"properties": {
+			"root": {"$ref": "#/definitions/node"},
+			"backup": {"$ref": "#/definitions/node"},
+			"build-id": {"type": "string"},
+			"build_id": {"type": "string"},
+			"validate": {"type": "boolean"},
+			"self": {"$ref": "#"}
+		},
+		"definitions": {
+			"node": {
+				"type": "object",
+				"required": ["name", "first"],
+				"properties": {
+					"name": {"type": "string"},
+					"children": {"type": "array", "items": {"$ref": "#/definitions/node"}},
+					"first": {"$ref": "#/definitions/node"}
+				}
+			}
+		}
+	}`)
+	if err != nil {
+		t.Fatalf("decode schema: %v", err)
+	}
+
+	source, err := generateArtifactTagTypes(schema, nil)
+	if err != nil {
+		t.Fatalf("generateArtifactTagTypes error: %v", err)
+	}
+	typeCheckGeneratedTags(t, source)
+	for _, want := range []string{
+		"Root      Node          `json:\"root\"`",
+		"Backup    *Node         `json:\"backup,omitempty\"`",
+		"Self      *ArtifactTags `json:\"self,omitempty\"`",
+		"BuildID   *string       `json:\"build-id,omitempty\"`",
+		"BuildID2  *string       `json:\"build_id,omitempty\"`",
+		"Validate2 *bool         `json:\"validate,omitempty\"`",
+		"Children []Node `json:\"children,omitempty\"`",
+		"First    *Node  `json:\"first\"`",
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Fatalf("generated source missing %q:\n%s", want, source)
+		}
+	}
+	if strings.Contains(string(source), "Node2") {
+		t.Fatalf("a reused $ref must not generate a second struct:\n%s", source)
+	}
+}
+
+// typeCheckGeneratedTags fails the test unless source compiles as a package.
+func typeCheckGeneratedTags(t *testing.T, source []byte) {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "tags.go", source, parser.AllErrors)
+	if err != nil {
+		t.Fatalf("generated source does not parse: %v\n%s", err, source)
+	}
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := config.Check(file.Name.Name, fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("generated source does not type-check: %v\n%s", err, source)
+	}
+}
+
+type testDecisionTags struct {
+	SchemaVersion string `json:"schema_version"`
+	Decision      string `json:"decision"`
+}
+
+func (v *testDecisionTags) Validate() error {
+	if v.Decision != "pass" && v.Decision != "fail" {
+		return errors.New("unexpected decision")
+	}
+	return nil
+}
+
+func TestTypedArtifactTagAccessors(t *testing.T) {
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":12,"tagSchemaVersion":"0.2.0","tags":"{\"schema_version\":\"0.2.0\",\"decision\":\"fail\"}"}}`))
+		case "/aiplorer/artifact/tag-schema":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"version":"0.2.0","content":"{\"version\":\"0.2.0\"}"}}`))
+		case "/aiplorer/artifact/update":
+			payload := decodeBody(t, r)
+			if !strings.Contains(payload["tags"].(string), `"decision":"pass"`) {
+				t.Fatalf("unexpected update payload: %#v", payload)
+			}
+			_, _ = w.Write([]byte(`{"code":0,"msg":"updated"}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	tags, err := GetArtifactTagsAs[testDecisionTags](service, 12)
+	if err != nil {
+		t.Fatalf("GetArtifactTagsAs error: %v", err)
+	}
+	if tags.Decision != "fail" || tags.SchemaVersion != "0.2.0" {
+		t.Fatalf("unexpected typed tags: %#v", tags)
+	}
+
+	tags.Decision = "unknown"
+	if _, err := UpdateArtifactTagsFrom(service, 12, tags, ""); err == nil {
+		t.Fatal("expected validation error for unknown decision")
+	}
+
+	tags.Decision = "pass"
+	if _, err := UpdateArtifactTagsFrom(service, 12, tags, ""); err != nil {
+		t.Fatalf("UpdateArtifactTagsFrom error: %v", err)
+	}
+}
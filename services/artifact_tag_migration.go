@@ -0,0 +1,317 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+func (s *artifactService) RegisterArtifactTagMigration(migration models.ArtifactTagMigration) error {
+	if strings.TrimSpace(migration.From) == "" || strings.TrimSpace(migration.To) == "" {
+		return utils.NewInvalidInputError("artifact tag migration requires from and to versions", nil)
+	}
+	if isTagVersionRange(migration.To) {
+		return utils.NewInvalidInputError(fmt.Sprintf("artifact tag migration target must be a concrete version: %s", migration.To), nil)
+	}
+	if migration.Migrate == nil && len(migration.Patch) == 0 {
+		return utils.NewInvalidInputError(fmt.Sprintf("artifact tag migration %s -> %s has no transform", migration.From, migration.To), nil)
+	}
+	s.tagMigrationsMu.Lock()
+	defer s.tagMigrationsMu.Unlock()
+	s.tagMigrations = append(s.tagMigrations, migration)
+	return nil
+}
+
+func (s *artifactService) UpgradeArtifactTags(tags map[string]any, targetVersion string) (map[string]any, error) {
+	if tags == nil {
+		tags = map[string]any{}
+	}
+	current, _ := tags["schema_version"].(string)
+	if targetVersion == "" || current == targetVersion {
+		return tags, nil
+	}
+	path := s.findArtifactTagMigrationPath(current, targetVersion)
+	if path == nil {
+		return nil, utils.NewAPIError(fmt.Sprintf("no artifact tag migration registered from %q to %q", current, targetVersion), nil)
+	}
+	upgraded := cloneJSONValue(tags).(map[string]any)
+	for _, step := range path {
+		var err error
+		upgraded, err = applyArtifactTagMigration(upgraded, step)
+		if err != nil {
+			return nil, utils.NewAPIError(fmt.Sprintf("artifact tag migration %s -> %s failed", step.From, step.To), err)
+		}
+		upgraded["schema_version"] = step.To
+	}
+	return upgraded, nil
+}
+
+func (s *artifactService) GetParsedArtifactTagsAtVersion(artifactID uint64, version string) (map[string]any, error) {
+	artifact, err := s.GetArtifactByID(artifactID)
+	if err != nil {
+		return nil, err
+	}
+	if artifact.Tags == nil || *artifact.Tags == "" {
+		return map[string]any{}, nil
+	}
+	schema, err := s.GetArtifactTagSchema(version)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := models.ParseJSON(*artifact.Tags)
+	if err != nil {
+		return nil, utils.NewAPIError("failed to decode artifact tags", err)
+	}
+	if _, ok := tags["schema_version"]; !ok && artifact.TagSchemaVersion != nil {
+		tags["schema_version"] = *artifact.TagSchemaVersion
+	}
+	if schema.Version != "" {
+		tags, err = s.UpgradeArtifactTags(tags, schema.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.ParseArtifactTags(mustJSON(tags), schema)
+}
+
+func (s *artifactService) MigrateArtifactTags(req *models.ArtifactTagMigrationReq) (*models.ArtifactTagMigrationReport, error) {
+	if req == nil {
+		return nil, utils.NewInvalidInputError("artifact tag migration request is nil", nil)
+	}
+	schema, err := s.GetArtifactTagSchema(req.TargetVersion)
+	if err != nil {
+		return nil, err
+	}
+	targetVersion := req.TargetVersion
+	if targetVersion == "" {
+		targetVersion = schema.Version
+	}
+	report := &models.ArtifactTagMigrationReport{
+		TargetVersion: targetVersion,
+		DryRun:        req.DryRun,
+		Results:       []models.ArtifactTagMigrationResult{},
+	}
+
+	visit := func(artifact models.ArtifactInfo) {
+		result := s.migrateOneArtifactTags(artifact, schema, targetVersion, req.DryRun)
+		report.Total++
+		switch result.Status {
+		case models.ArtifactTagMigrationMigrated, models.ArtifactTagMigrationWouldMigrate:
+			report.Migrated++
+		case models.ArtifactTagMigrationFailed:
+			report.Failed++
+		default:
+			report.Skipped++
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	if len(req.ArtifactIDs) > 0 {
+		for _, id := range req.ArtifactIDs {
+			artifact, err := s.GetArtifactByID(id)
+			if err != nil {
+				report.Total++
+				report.Failed++
+				report.Results = append(report.Results, models.ArtifactTagMigrationResult{
+					ArtifactID: id,
+					Status:     models.ArtifactTagMigrationFailed,
+					Error:      err.Error(),
+				})
+				continue
+			}
+			visit(*artifact)
+		}
+		return report, nil
+	}
+
+	// Migrating changes the fields a filter such as TagSchemaVersion selects
+	// on, which would shift later pages; list every match before writing.
+	var artifacts []models.ArtifactInfo
+	if err := s.forEachArtifact(req.Filter, func(item models.ArtifactInfo) (bool, error) {
+		artifacts = append(artifacts, item)
+		return true, nil
+	}); err != nil {
+		return report, err
+	}
+	for _, artifact := range artifacts {
+		visit(artifact)
+	}
+	return report, nil
+}
+
+func (s *artifactService) migrateOneArtifactTags(artifact models.ArtifactInfo, schema *models.ArtifactTagSchemaInfo, targetVersion string, dryRun bool) models.ArtifactTagMigrationResult {
+	result := models.ArtifactTagMigrationResult{Name: artifact.Name, ToVersion: targetVersion}
+	if artifact.ID != nil {
+		result.ArtifactID = *artifact.ID
+	}
+	fail := func(err error) models.ArtifactTagMigrationResult {
+		result.Status = models.ArtifactTagMigrationFailed
+		result.Error = err.Error()
+		return result
+	}
+	if artifact.ID == nil {
+		return fail(fmt.Errorf("artifact id is empty"))
+	}
+	// upgrade derives the tags to write, or nil when there is nothing to
+	// migrate. It runs again on the latest artifact before writing.
+	upgrade := func(artifact *models.ArtifactInfo) (map[string]any, error) {
+		result.FromVersion, result.Steps, result.Tags = "", nil, nil
+		if artifact.Tags == nil || strings.TrimSpace(*artifact.Tags) == "" {
+			return nil, nil
+		}
+		tags, err := models.ParseJSON(*artifact.Tags)
+		if err != nil {
+			return nil, err
+		}
+		fromVersion, _ := tags["schema_version"].(string)
+		if fromVersion == "" {
+			fromVersion = valueOrEmpty(artifact.TagSchemaVersion)
+			tags["schema_version"] = fromVersion
+		}
+		result.FromVersion = fromVersion
+		if fromVersion == targetVersion {
+			return nil, nil
+		}
+		for _, step := range s.findArtifactTagMigrationPath(fromVersion, targetVersion) {
+			result.Steps = append(result.Steps, step.From+" -> "+step.To)
+		}
+		upgraded, err := s.UpgradeArtifactTags(tags, targetVersion)
+		if err != nil {
+			return nil, err
+		}
+		result.Tags = upgraded
+		if _, err := s.validateArtifactTagsWithSchema(upgraded, schema); err != nil {
+			return nil, err
+		}
+		return upgraded, nil
+	}
+
+	upgraded, err := upgrade(&artifact)
+	if err != nil {
+		return fail(err)
+	}
+	if upgraded == nil {
+		result.Status = models.ArtifactTagMigrationSkipped
+		return result
+	}
+	if dryRun {
+		result.Status = models.ArtifactTagMigrationWouldMigrate
+		return result
+	}
+	// Write only while the artifact is unchanged so tags a pipeline writes
+	// during the run are migrated rather than overwritten.
+	written := false
+	if _, _, err := s.updateArtifactIfUnchanged(*artifact.ID, defaultTagPatchMaxRetries, func(latest *models.ArtifactInfo) (*models.ArtifactInfo, error) {
+		upgraded, err := upgrade(latest)
+		written = upgraded != nil
+		if err != nil || upgraded == nil {
+			return nil, err
+		}
+		return &models.ArtifactInfo{
+			Tags:             stringPtr(mustJSON(upgraded)),
+			TagSchemaVersion: stringPtr(targetVersion),
+		}, nil
+	}); err != nil {
+		return fail(err)
+	}
+	if !written {
+		result.Status = models.ArtifactTagMigrationSkipped
+		return result
+	}
+	result.Status = models.ArtifactTagMigrationMigrated
+	return result
+}
+
+func (s *artifactService) hasArtifactTagMigrationPath(from, to string) bool {
+	return s.findArtifactTagMigrationPath(from, to) != nil
+}
+
+// findArtifactTagMigrationPath returns the shortest chain of registered
+// migrations leading from one version to another, or nil when none exists.
+func (s *artifactService) findArtifactTagMigrationPath(from, to string) []models.ArtifactTagMigration {
+	s.tagMigrationsMu.RLock()
+	migrations := append([]models.ArtifactTagMigration(nil), s.tagMigrations...)
+	s.tagMigrationsMu.RUnlock()
+
+	type edge struct {
+		prev      string
+		migration models.ArtifactTagMigration
+	}
+	visited := map[string]edge{from: {}}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			var path []models.ArtifactTagMigration
+			for node := to; node != from; node = visited[node].prev {
+				path = append([]models.ArtifactTagMigration{visited[node].migration}, path...)
+			}
+			return path
+		}
+		for _, migration := range migrations {
+			if !tagVersionMatches(migration.From, current) {
+				continue
+			}
+			if _, seen := visited[migration.To]; seen {
+				continue
+			}
+			visited[migration.To] = edge{prev: current, migration: migration}
+			queue = append(queue, migration.To)
+		}
+	}
+	return nil
+}
+
+func applyArtifactTagMigration(tags map[string]any, migration models.ArtifactTagMigration) (map[string]any, error) {
+	if migration.Migrate != nil {
+		migrated, err := migration.Migrate(tags)
+		if err != nil {
+			return nil, err
+		}
+		if migrated == nil {
+			return nil, fmt.Errorf("migration returned nil tags")
+		}
+		return migrated, nil
+	}
+	return applyArtifactTagPatch(tags, migration.PatchType, migration.Patch)
+}
+
+// isTagVersionRange reports whether version is a range such as 0.2.x, * or
+// ^0.2.0 rather than one concrete version. Versions that are neither semver
+// nor a constraint, such as "draft", are concrete labels.
+func isTagVersionRange(version string) bool {
+	text := strings.TrimSpace(version)
+	if _, err := parseSemver(text); err == nil {
+		return false
+	}
+	if strings.EqualFold(text, "x") {
+		return true
+	}
+	_, err := parseSemverConstraint(text)
+	return err == nil
+}
+
+// tagVersionMatches reports whether version matches a migration's From: the
+// same version or label, a catch-all * or x that also matches tags without a
+// version, or a semver range such as 0.1.x or ^0.1.0.
+func tagVersionMatches(pattern, version string) bool {
+	if pattern == version {
+		return true
+	}
+	text := strings.TrimSpace(pattern)
+	if text == "*" || strings.EqualFold(text, "x") {
+		return true
+	}
+	if !isTagVersionRange(text) {
+		return false
+	}
+	parsed, err := parseSemver(version)
+	if err != nil {
+		return false
+	}
+	constraint, err := parseSemverConstraint(text)
+	return err == nil && constraint.matches(parsed)
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func registerTestTagMigrations(t *testing.T, service *artifactService) {
+	t.Helper()
+	if err := service.RegisterArtifactTagMigration(models.ArtifactTagMigration{
+		From:      "0.1.x",
+		To:        "0.1.9",
+		PatchType: models.ArtifactTagJSONPatch,
+		Patch:     json.RawMessage(`[{"op":"move","from":"/result","path":"/decision"}]`),
+	}); err != nil {
+		t.Fatalf("register patch migration: %v", err)
+	}
+	if err := service.RegisterArtifactTagMigration(models.ArtifactTagMigration{
+		From: "0.1.9",
+		To:   "0.2.0",
+		Migrate: func(tags map[string]any) (map[string]any, error) {
+			tags["decision_basis"] = map[string]any{"compare_result": tags["compare"]}
+			delete(tags, "compare")
+			return tags, nil
+		},
+	}); err != nil {
+		t.Fatalf("register func migration: %v", err)
+	}
+}
+
+func TestParseArtifactTagsUpgradesThroughRegisteredMigrations(t *testing.T) {
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request: %s", r.URL.Path)
+	})
+
+	if _, err := service.ParseArtifactTags(`{"schema_version":"0.1.3","result":"pass"}`, testTagSchema); err == nil {
+		t.Fatal("expected version mismatch without registered migrations")
+	}
+
+	registerTestTagMigrations(t, service)
+	parsed, err := service.ParseArtifactTags(`{"schema_version":"0.1.3","result":"pass","compare":"optimized"}`, testTagSchema)
+	if err != nil {
+		t.Fatalf("ParseArtifactTags error: %v", err)
+	}
+	basis, _ := parsed["decision_basis"].(map[string]any)
+	if parsed["schema_version"] != "0.2.0" || parsed["decision"] != "pass" || basis["compare_result"] != "optimized" {
+		t.Fatalf("unexpected upgraded tags: %#v", parsed)
+	}
+}
+
+func TestMigrateArtifactTagsDryRunAndApply(t *testing.T) {
+	var updated []float64
+	reads := 0
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			if payload := decodeBody(t, r); payload["id"] != float64(1) {
+				t.Fatalf("only artifact 1 is written: %#v", payload)
+			}
+			reads++
+			// A pipeline tags the artifact while the migration runs.
+			if reads == 1 {
+				_, _ = w.Write([]byte(`{"code":0,"data":{"id":1,"updatedAt":100,"tagSchemaVersion":"0.1.3","tags":"{\"schema_version\":\"0.1.3\",\"result\":\"pass\",\"compare\":\"same\"}"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":1,"updatedAt":101,"tagSchemaVersion":"0.1.3","tags":"{\"schema_version\":\"0.1.3\",\"result\":\"pass\",\"compare\":\"same\",\"owner\":\"ci\"}"}}`))
+		case "/aiplorer/artifact/tag-schema":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"version":"0.2.0","content":` + mustJSON(testTagSchema) + `}}`))
+		case "/aiplorer/artifact/list":
+			payload := decodeBody(t, r)
+			if payload["tagSchemaVersion"].(string) != "0.1.3" {
+				t.Fatalf("unexpected list payload: %#v", payload)
+			}
+			_, _ = w.Write([]byte(`{"code":0,"data":{"total":3,"data":[` +
+				`{"id":1,"name":"a","tagSchemaVersion":"0.1.3","tags":"{\"schema_version\":\"0.1.3\",\"result\":\"pass\",\"compare\":\"same\"}"},` +
+				`{"id":2,"name":"b","tagSchemaVersion":"0.1.3","tags":"{\"schema_version\":\"0.1.3\",\"result\":\"bogus\"}"},` +
+				`{"id":3,"name":"c"}]}}`))
+		case "/aiplorer/artifact/update":
+			payload := decodeBody(t, r)
+			if payload["tagSchemaVersion"].(string) != "0.2.0" || !strings.Contains(payload["tags"].(string), `"decision":"pass"`) {
+				t.Fatalf("unexpected update payload: %#v", payload)
+			}
+			updated = append(updated, payload["id"].(float64))
+			_, _ = w.Write([]byte(`{"code":0,"msg":"updated"}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+	registerTestTagMigrations(t, service)
+
+	fromVersion := "0.1.3"
+	req := &models.ArtifactTagMigrationReq{
+		Filter:        &models.ArtifactListReq{TagSchemaVersion: &fromVersion},
+		TargetVersion: "0.2.0",
+		DryRun:        true,
+	}
+	report, err := service.MigrateArtifactTags(req)
+	if err != nil {
+		t.Fatalf("MigrateArtifactTags dry run error: %v", err)
+	}
+	if report.Total != 3 || report.Migrated != 1 || report.Failed != 1 || report.Skipped != 1 {
+		t.Fatalf("unexpected dry run report: %#v", report)
+	}
+	if report.Results[0].Status != models.ArtifactTagMigrationWouldMigrate || len(report.Results[0].Steps) != 2 {
+		t.Fatalf("unexpected dry run result: %#v", report.Results[0])
+	}
+	if len(updated) != 0 {
+		t.Fatalf("dry run must not write tags, got updates for %v", updated)
+	}
+
+	req.DryRun = false
+	report, err = service.MigrateArtifactTags(req)
+	if err != nil {
+		t.Fatalf("MigrateArtifactTags apply error: %v", err)
+	}
+	if report.Results[0].Status != models.ArtifactTagMigrationMigrated || len(updated) != 1 || updated[0] != 1 {
+		t.Fatalf("unexpected apply report: %#v updates=%v", report, updated)
+	}
+	if report.Results[0].Tags["owner"] != "ci" {
+		t.Fatalf("tags written during the run must be migrated, not overwritten: %#v", report.Results[0].Tags)
+	}
+}
+
+func TestMigrateArtifactTagsDoesNotSkipRowsLeavingTheFilter(t *testing.T) {
+	versions := map[int]string{1: "0.1.3", 2: "0.1.3", 3: "0.1.3"}
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeBody(t, r)
+		switch r.URL.Path {
+		case "/aiplorer/artifact/tag-schema":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"version":"0.2.0","content":` + mustJSON(testTagSchema) + `}}`))
+		case "/aiplorer/artifact/list":
+			// The server filters on the current tag schema version, so migrated
+			// rows leave the result set.
+			var matching []map[string]any
+			for id := 1; id <= 3; id++ {
+				if versions[id] == payload["tagSchemaVersion"] {
+					matching = append(matching, map[string]any{
+						"id":               id,
+						"tagSchemaVersion": versions[id],
+						"tags":             `{"schema_version":"0.1.3","result":"pass","compare":"same"}`,
+					})
+				}
+			}
+			page, pageSize := int(payload["page"].(float64)), int(payload["pageSize"].(float64))
+			items := []map[string]any{}
+			if start := (page - 1) * pageSize; start < len(matching) {
+				items = matching[start:min(start+pageSize, len(matching))]
+			}
+			_, _ = w.Write([]byte(`{"code":0,"data":{"total":` + mustJSON(len(matching)) + `,"data":` + mustJSON(items) + `}}`))
+		case "/aiplorer/artifact":
+			id := int(payload["id"].(float64))
+			_, _ = w.Write([]byte(`{"code":0,"data":` + mustJSON(map[string]any{
+				"id":               id,
+				"updatedAt":        100,
+				"tagSchemaVersion": versions[id],
+				"tags":             `{"schema_version":"` + versions[id] + `","result":"pass","compare":"same"}`,
+			}) + `}`))
+		case "/aiplorer/artifact/update":
+			versions[int(payload["id"].(float64))] = payload["tagSchemaVersion"].(string)
+			_, _ = w.Write([]byte(`{"code":0,"msg":"updated"}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+	registerTestTagMigrations(t, service)
+
+	fromVersion := "0.1.3"
+	report, err := service.MigrateArtifactTags(&models.ArtifactTagMigrationReq{
+		Filter:        &models.ArtifactListReq{TagSchemaVersion: &fromVersion, PageSize: 1},
+		TargetVersion: "0.2.0",
+	})
+	if err != nil {
+		t.Fatalf("MigrateArtifactTags error: %v", err)
+	}
+	if report.Migrated != 3 {
+		t.Fatalf("expected every matching artifact to be migrated, got %#v", report)
+	}
+	for id, version := range versions {
+		if version != "0.2.0" {
+			t.Fatalf("artifact %d left at %s", id, version)
+		}
+	}
+}
+
+func TestRegisterArtifactTagMigrationRejectsRangeTargets(t *testing.T) {
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request: %s", r.URL.Path)
+	})
+	patch := json.RawMessage(`{"owner":null}`)
+	for _, target := range []string{"0.2.x", "0.X", "*", "x", "^0.2.0", ">=0.2.0"} {
+		if err := service.RegisterArtifactTagMigration(models.ArtifactTagMigration{From: "0.1.x", To: target, Patch: patch}); err == nil {
+			t.Fatalf("range target %q must be rejected", target)
+		}
+	}
+	for _, target := range []string{"1.0.0-fix.x", "0.2.0", "v2", "draft"} {
+		if err := service.RegisterArtifactTagMigration(models.ArtifactTagMigration{From: "0.1.x", To: target, Patch: patch}); err != nil {
+			t.Fatalf("concrete target %q rejected: %v", target, err)
+		}
+	}
+}
+
+func TestArtifactTagMigrationFromRanges(t *testing.T) {
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request: %s", r.URL.Path)
+	})
+	for _, migration := range []models.ArtifactTagMigration{
+		{From: "*", To: "0.1.0", Patch: json.RawMessage(`{"origin":"legacy"}`)},
+		{From: "^0.1.0", To: "0.2.0", Patch: json.RawMessage(`{"caret":true}`)},
+	} {
+		if err := service.RegisterArtifactTagMigration(migration); err != nil {
+			t.Fatalf("register %s -> %s: %v", migration.From, migration.To, err)
+		}
+	}
+
+	upgraded, err := service.UpgradeArtifactTags(nil, "0.2.0")
+	if err != nil {
+		t.Fatalf("UpgradeArtifactTags(nil) error: %v", err)
+	}
+	if upgraded["schema_version"] != "0.2.0" || upgraded["origin"] != "legacy" || upgraded["caret"] != true {
+		t.Fatalf("unexpected upgraded tags: %#v", upgraded)
+	}
+	if upgraded, err = service.UpgradeArtifactTags(map[string]any{"schema_version": "0.1.7"}, "0.2.0"); err != nil || upgraded["caret"] != true {
+		t.Fatalf("caret ranges must match multi-segment versions: %#v %v", upgraded, err)
+	}
+	if !tagVersionMatches("0.1.x", "0.1.3") || tagVersionMatches("0.1.x", "0.2.0") || tagVersionMatches("^0.1.0", "draft") {
+		t.Fatal("unexpected tagVersionMatches result")
+	}
+}
@@ -0,0 +1,463 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+const defaultTagPatchMaxRetries = 3
+
+func (s *artifactService) PatchArtifactTags(artifactID uint64, req *models.ArtifactTagPatchReq) (*models.ArtifactTagPatchResult, error) {
+	if req == nil || len(req.Patch) == 0 {
+		return nil, utils.NewInvalidInputError("artifact tag patch is empty", nil)
+	}
+	maxRetries := req.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultTagPatchMaxRetries
+	}
+
+	schemas := map[string]*models.ArtifactTagSchemaInfo{}
+	var written map[string]any
+	var tagSchemaVersion string
+	response, attempts, err := s.updateArtifactIfUnchanged(artifactID, maxRetries, func(artifact *models.ArtifactInfo) (*models.ArtifactInfo, error) {
+		current, err := models.ParseJSON(valueOrEmpty(artifact.Tags))
+		if err != nil {
+			return nil, utils.NewAPIError("failed to decode artifact tags", err)
+		}
+		patched, err := applyArtifactTagPatch(current, req.Type, req.Patch)
+		if err != nil {
+			return nil, err
+		}
+
+		tagSchemaVersion = req.TagSchemaVersion
+		if tagSchemaVersion == "" {
+			if rawVersion, ok := patched["schema_version"].(string); ok {
+				tagSchemaVersion = rawVersion
+			}
+		}
+		if tagSchemaVersion == "" {
+			tagSchemaVersion = valueOrEmpty(artifact.TagSchemaVersion)
+		}
+		schema, ok := schemas[tagSchemaVersion]
+		if !ok {
+			schema, err = s.GetArtifactTagSchema(tagSchemaVersion)
+			if err != nil {
+				return nil, err
+			}
+			schemas[tagSchemaVersion] = schema
+		}
+		written, err = s.validateArtifactTagsWithSchema(patched, schema)
+		if err != nil {
+			return nil, err
+		}
+		return &models.ArtifactInfo{
+			Tags:             stringPtr(mustJSON(written)),
+			TagSchemaVersion: stringPtr(tagSchemaVersion),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.ArtifactTagPatchResult{
+		ArtifactID:       artifactID,
+		Tags:             written,
+		TagSchemaVersion: tagSchemaVersion,
+		Attempts:         attempts,
+		Response:         response,
+	}, nil
+}
+
+// updateArtifactIfUnchanged reads the artifact, derives an update from it
+// with build and writes it only while the artifact's UpdatedAt is unchanged.
+// The artifact is read again right before writing and compared, and the
+// UpdatedAt is also sent as expectedUpdatedAt for servers that enforce it.
+// When another writer got there first the update is derived again from the
+// latest artifact, at most maxRetries times. Artifacts without an UpdatedAt
+// cannot be checked and are refused. A nil update from build writes nothing.
+func (s *artifactService) updateArtifactIfUnchanged(artifactID uint64, maxRetries int, build func(artifact *models.ArtifactInfo) (*models.ArtifactInfo, error)) (*models.BaseMsgResp, int, error) {
+	for attempt := 1; attempt <= maxRetries+1; attempt++ {
+		artifact, err := s.GetArtifactByID(artifactID)
+		if err != nil {
+			return nil, attempt, err
+		}
+		if artifact.UpdatedAt == nil {
+			return nil, attempt, utils.NewAPIError(fmt.Sprintf("artifact %d has no updatedAt, refusing an update that could overwrite concurrent changes", artifactID), nil)
+		}
+		update, err := build(artifact)
+		if err != nil {
+			return nil, attempt, err
+		}
+		if update == nil {
+			return nil, attempt, nil
+		}
+		update.ID = &artifactID
+		response, err := s.updateArtifactIfStill(update, *artifact.UpdatedAt)
+		if err == nil {
+			return response, attempt, nil
+		}
+		var sdkErr *utils.SDKError
+		if !errors.As(err, &sdkErr) || sdkErr.Code != utils.ErrCodeConflict {
+			return nil, attempt, err
+		}
+		utils.Debug("Artifact %d modified concurrently, retrying update (attempt %d)", artifactID, attempt)
+	}
+	return nil, maxRetries + 1, utils.NewConflictError(fmt.Sprintf("artifact modified concurrently, gave up after %d attempts: %d", maxRetries+1, artifactID), nil)
+}
+
+// updateArtifactIfStill re-reads the artifact and writes update only while
+// its UpdatedAt still equals expectedUpdatedAt.
+func (s *artifactService) updateArtifactIfStill(update *models.ArtifactInfo, expectedUpdatedAt int64) (*models.BaseMsgResp, error) {
+	latest, err := s.GetArtifactByID(*update.ID)
+	if err != nil {
+		return nil, err
+	}
+	if latest.UpdatedAt == nil || *latest.UpdatedAt != expectedUpdatedAt {
+		return nil, utils.NewConflictError(fmt.Sprintf("artifact %d changed since it was read", *update.ID), nil)
+	}
+	return s.updateArtifactConditionally(update, &expectedUpdatedAt)
+}
+
+func (s *artifactService) updateArtifactConditionally(update *models.ArtifactInfo, expectedUpdatedAt *int64) (*models.BaseMsgResp, error) {
+	var response models.BaseMsgResp
+	req := &models.ArtifactConditionalUpdateReq{ArtifactInfo: *update, ExpectedUpdatedAt: expectedUpdatedAt}
+	if err := s.httpClient.Post("/aiplorer/artifact/update", req, &response); err != nil {
+		var sdkErr *utils.SDKError
+		if errors.As(err, &sdkErr) && sdkErr.Code == utils.ErrCodeConflict {
+			return nil, err
+		}
+		return nil, utils.NewAPIError("failed to update artifact", err)
+	}
+	if response.Code == http.StatusConflict {
+		return nil, utils.NewConflictError(response.Msg, nil)
+	}
+	if response.Code != 0 {
+		return nil, utils.NewAPIError(response.Msg, nil)
+	}
+	return &response, nil
+}
+
+// validateArtifactTagsWithSchema upgrades tags to the schema version when a
+// migration path exists and validates the result, which is what callers
+// must write.
+func (s *artifactService) validateArtifactTagsWithSchema(tags map[string]any, schema *models.ArtifactTagSchemaInfo) (map[string]any, error) {
+	upgraded, err := s.ParseArtifactTags(mustJSON(tags), schema)
+	if err != nil {
+		return nil, err
+	}
+	parsedSchema, err := models.ParseJSON(schema.Content)
+	if err != nil {
+		return nil, utils.NewAPIError("failed to decode artifact tag schema", err)
+	}
+	if err := validateArtifactTags(upgraded, parsedSchema); err != nil {
+		return nil, err
+	}
+	return upgraded, nil
+}
+
+func applyArtifactTagPatch(tags map[string]any, patchType models.ArtifactTagPatchType, patch json.RawMessage) (map[string]any, error) {
+	var patched any
+	switch patchType {
+	case models.ArtifactTagMergePatch, "":
+		var doc any
+		if err := json.Unmarshal(patch, &doc); err != nil {
+			return nil, utils.NewInvalidInputError("failed to decode artifact tag merge patch", err)
+		}
+		patched = applyMergePatch(tags, doc)
+	case models.ArtifactTagJSONPatch:
+		var ops []jsonPatchOperation
+		if err := json.Unmarshal(patch, &ops); err != nil {
+			return nil, utils.NewInvalidInputError("failed to decode artifact tag json patch", err)
+		}
+		var err error
+		patched, err = applyJSONPatch(tags, ops)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, utils.NewInvalidInputError(fmt.Sprintf("unsupported artifact tag patch type: %s", patchType), nil)
+	}
+	result, ok := patched.(map[string]any)
+	if !ok {
+		return nil, utils.NewInvalidInputError("patched artifact tags must be a JSON object", nil)
+	}
+	return result, nil
+}
+
+// applyMergePatch applies an RFC 7396 merge patch to target.
+func applyMergePatch(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = applyMergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// applyJSONPatch applies an RFC 6902 JSON Patch document to doc.
+func applyJSONPatch(doc any, ops []jsonPatchOperation) (any, error) {
+	for i, op := range ops {
+		var err error
+		doc, err = applyJSONPatchOperation(doc, op)
+		if err != nil {
+			return nil, utils.NewInvalidInputError(fmt.Sprintf("json patch operation %d (%s %s) failed", i, op.Op, op.Path), err)
+		}
+	}
+	return doc, nil
+}
+
+func applyJSONPatchOperation(doc any, op jsonPatchOperation) (any, error) {
+	path, err := parseJSONPointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, fmt.Errorf("missing value")
+		}
+		var value any
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, err
+		}
+		switch op.Op {
+		case "add":
+			return jsonPointerAdd(doc, path, value)
+		case "replace":
+			return jsonPointerReplace(doc, path, value)
+		default:
+			current, err := jsonPointerGet(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("test failed")
+			}
+			return doc, nil
+		}
+	case "remove":
+		updated, _, err := jsonPointerRemove(doc, path)
+		return updated, err
+	case "move", "copy":
+		from, err := parseJSONPointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if op.From == op.Path {
+				return doc, nil
+			}
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, fmt.Errorf("cannot move a value into one of its children")
+			}
+			updated, value, err := jsonPointerRemove(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			return jsonPointerAdd(updated, path, value)
+		}
+		value, err := jsonPointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return jsonPointerAdd(doc, path, cloneJSONValue(value))
+	default:
+		return nil, fmt.Errorf("unsupported op: %s", op.Op)
+	}
+}
+
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid json pointer: %s", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func jsonPointerGet(doc any, path []string) (any, error) {
+	current := doc
+	for _, token := range path {
+		switch typed := current.(type) {
+		case map[string]any:
+			value, ok := typed[token]
+			if !ok {
+				return nil, fmt.Errorf("path not found: %s", token)
+			}
+			current = value
+		case []any:
+			index, err := jsonPointerIndex(token, len(typed)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = typed[index]
+		default:
+			return nil, fmt.Errorf("path not found: %s", token)
+		}
+	}
+	return current, nil
+}
+
+// jsonPointerUpdate walks to the container holding the last path token and
+// lets fn rewrite it, propagating the rewritten container back to the root.
+func jsonPointerUpdate(doc any, path []string, fn func(container any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	switch typed := doc.(type) {
+	case map[string]any:
+		child, ok := typed[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("path not found: %s", path[0])
+		}
+		updated, err := jsonPointerUpdate(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		typed[path[0]] = updated
+		return typed, nil
+	case []any:
+		index, err := jsonPointerIndex(path[0], len(typed)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := jsonPointerUpdate(typed[index], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		typed[index] = updated
+		return typed, nil
+	default:
+		return nil, fmt.Errorf("path not found: %s", path[0])
+	}
+}
+
+func jsonPointerAdd(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return jsonPointerUpdate(doc, path, func(container any, token string) (any, error) {
+		switch typed := container.(type) {
+		case map[string]any:
+			typed[token] = value
+			return typed, nil
+		case []any:
+			if token == "-" {
+				return append(typed, value), nil
+			}
+			index, err := jsonPointerIndex(token, len(typed))
+			if err != nil {
+				return nil, err
+			}
+			typed = append(typed, nil)
+			copy(typed[index+1:], typed[index:])
+			typed[index] = value
+			return typed, nil
+		default:
+			return nil, fmt.Errorf("cannot add to non-container value at %s", token)
+		}
+	})
+}
+
+func jsonPointerReplace(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return jsonPointerUpdate(doc, path, func(container any, token string) (any, error) {
+		switch typed := container.(type) {
+		case map[string]any:
+			if _, ok := typed[token]; !ok {
+				return nil, fmt.Errorf("path not found: %s", token)
+			}
+			typed[token] = value
+			return typed, nil
+		case []any:
+			index, err := jsonPointerIndex(token, len(typed)-1)
+			if err != nil {
+				return nil, err
+			}
+			typed[index] = value
+			return typed, nil
+		default:
+			return nil, fmt.Errorf("path not found: %s", token)
+		}
+	})
+}
+
+func jsonPointerRemove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the document root")
+	}
+	var removed any
+	updated, err := jsonPointerUpdate(doc, path, func(container any, token string) (any, error) {
+		switch typed := container.(type) {
+		case map[string]any:
+			value, ok := typed[token]
+			if !ok {
+				return nil, fmt.Errorf("path not found: %s", token)
+			}
+			removed = value
+			delete(typed, token)
+			return typed, nil
+		case []any:
+			index, err := jsonPointerIndex(token, len(typed)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = typed[index]
+			return append(typed[:index], typed[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("path not found: %s", token)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated, removed, nil
+}
+
+func jsonPointerIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index: %s", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max {
+		return 0, fmt.Errorf("array index out of range: %s", token)
+	}
+	return index, nil
+}
+
+func cloneJSONValue(value any) any {
+	var cloned any
+	_ = json.Unmarshal([]byte(mustJSON(value)), &cloned)
+	return cloned
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+const testTagSchema = `{"version":"0.2.0","type":"object","required":["schema_version","decision"],"properties":{"schema_version":{"type":"string"},"decision":{"type":"string","enum":["pass","fail","pending"]},"decision_basis":{"type":"object","properties":{"compare_result":{"type":"string"}}}}}`
+
+func TestPatchArtifactTagsAppliesMergePatch(t *testing.T) {
+	var written map[string]any
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":12,"updatedAt":100,"tagSchemaVersion":"0.2.0","tags":"{\"schema_version\":\"0.2.0\",\"decision\":\"pending\",\"decision_basis\":{\"compare_result\":\"same\"},\"owner\":\"ci\"}"}}`))
+		case "/aiplorer/artifact/tag-schema":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"version":"0.2.0","content":` + mustJSON(testTagSchema) + `}}`))
+		case "/aiplorer/artifact/update":
+			payload := decodeBody(t, r)
+			if payload["expectedUpdatedAt"] != float64(100) {
+				t.Fatalf("update must carry the updatedAt it was derived from: %#v", payload["expectedUpdatedAt"])
+			}
+			if err := json.Unmarshal([]byte(payload["tags"].(string)), &written); err != nil {
+				t.Fatalf("decode written tags: %v", err)
+			}
+			_, _ = w.Write([]byte(`{"code":0,"msg":"updated"}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	result, err := service.PatchArtifactTags(12, &models.ArtifactTagPatchReq{
+		Type:  models.ArtifactTagMergePatch,
+		Patch: json.RawMessage(`{"decision":"pass","decision_basis":{"compare_result":"optimized"},"owner":null}`),
+	})
+	if err != nil {
+		t.Fatalf("PatchArtifactTags error: %v", err)
+	}
+	want := map[string]any{
+		"schema_version": "0.2.0",
+		"decision":       "pass",
+		"decision_basis": map[string]any{"compare_result": "optimized"},
+	}
+	if !reflect.DeepEqual(written, want) || !reflect.DeepEqual(result.Tags, want) {
+		t.Fatalf("unexpected patched tags: written=%#v result=%#v", written, result.Tags)
+	}
+	if result.Attempts != 1 || result.TagSchemaVersion != "0.2.0" {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+}
+
+func TestPatchArtifactTagsRetriesOnConcurrentModification(t *testing.T) {
+	reads := 0
+	updates := 0
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			reads++
+			// Another pipeline writes between the first read and the
+			// check right before the update.
+			updatedAt := 200
+			if reads == 1 {
+				updatedAt = 100
+			}
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"code":0,"data":{"id":12,"updatedAt":%d,"tags":"{\"schema_version\":\"0.2.0\",\"decision\":\"pending\"}"}}`, updatedAt)))
+		case "/aiplorer/artifact/tag-schema":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"version":"0.2.0","content":` + mustJSON(testTagSchema) + `}}`))
+		case "/aiplorer/artifact/update":
+			updates++
+			if payload := decodeBody(t, r); payload["expectedUpdatedAt"] != float64(200) {
+				t.Fatalf("stale updates must not be sent: %#v", payload["expectedUpdatedAt"])
+			}
+			// The first write loses a race the server detects itself and
+			// reports in the response envelope.
+			if updates == 1 {
+				_, _ = w.Write([]byte(`{"code":409,"msg":"artifact was modified"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":0,"msg":"updated"}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	result, err := service.PatchArtifactTags(12, &models.ArtifactTagPatchReq{
+		Type:  models.ArtifactTagJSONPatch,
+		Patch: json.RawMessage(`[{"op":"replace","path":"/decision","value":"pass"}]`),
+	})
+	if err != nil {
+		t.Fatalf("PatchArtifactTags error: %v", err)
+	}
+	if result.Attempts != 3 || updates != 2 {
+		t.Fatalf("expected a stale read, a rejected and an applied update, got attempts=%d updates=%d", result.Attempts, updates)
+	}
+}
+
+func TestPatchArtifactTagsRefusesArtifactWithoutUpdatedAt(t *testing.T) {
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":12,"tags":"{\"schema_version\":\"0.2.0\",\"decision\":\"pending\"}"}}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	if _, err := service.PatchArtifactTags(12, &models.ArtifactTagPatchReq{Patch: json.RawMessage(`{"decision":"pass"}`)}); err == nil {
+		t.Fatal("artifacts without updatedAt must not be overwritten blindly")
+	}
+}
+
+func TestPatchArtifactTagsGivesUpWithConflict(t *testing.T) {
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":12,"updatedAt":100,"tags":"{\"schema_version\":\"0.2.0\",\"decision\":\"pending\"}"}}`))
+		case "/aiplorer/artifact/tag-schema":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"version":"0.2.0","content":` + mustJSON(testTagSchema) + `}}`))
+		case "/aiplorer/artifact/update":
+			w.WriteHeader(http.StatusConflict)
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	_, err := service.PatchArtifactTags(12, &models.ArtifactTagPatchReq{
+		Patch:      json.RawMessage(`{"decision":"pass"}`),
+		MaxRetries: 1,
+	})
+	var sdkErr *utils.SDKError
+	if !errors.As(err, &sdkErr) || sdkErr.Code != utils.ErrCodeConflict {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+}
+
+func TestPatchArtifactTagsRejectsSchemaViolation(t *testing.T) {
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":12,"updatedAt":100,"tags":"{\"schema_version\":\"0.2.0\",\"decision\":\"pending\"}"}}`))
+		case "/aiplorer/artifact/tag-schema":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"version":"0.2.0","content":` + mustJSON(testTagSchema) + `}}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	_, err := service.PatchArtifactTags(12, &models.ArtifactTagPatchReq{
+		Type:  models.ArtifactTagJSONPatch,
+		Patch: json.RawMessage(`[{"op":"remove","path":"/decision"}]`),
+	})
+	sdkErr, ok := err.(*utils.SDKError)
+	if !ok || sdkErr.Code != utils.ErrCodeInvalidInput {
+		t.Fatalf("expected invalid input error, got %v", err)
+	}
+}
+
+func TestApplyJSONPatchOperations(t *testing.T) {
+	doc := map[string]any{
+		"a":    map[string]any{"b": "c"},
+		"list": []any{"x", "z"},
+	}
+	var ops []jsonPatchOperation
+	if err := json.Unmarshal([]byte(`[
+		{"op":"add","path":"/list/1","value":"y"},
+		{"op":"add","path":"/list/-","value":"end"},
+		{"op":"copy","from":"/a/b","path":"/copied"},
+		{"op":"move","from":"/a/b","path":"/a~1moved"},
+		{"op":"test","path":"/copied","value":"c"},
+		{"op":"remove","path":"/list/0"}
+	]`), &ops); err != nil {
+		t.Fatalf("decode ops: %v", err)
+	}
+
+	patched, err := applyJSONPatch(doc, ops)
+	if err != nil {
+		t.Fatalf("applyJSONPatch error: %v", err)
+	}
+	want := map[string]any{
+		"a":       map[string]any{},
+		"a/moved": "c",
+		"copied":  "c",
+		"list":    []any{"y", "z", "end"},
+	}
+	if !reflect.DeepEqual(patched, want) {
+		t.Fatalf("unexpected patched doc: %#v", patched)
+	}
+
+	if _, err := applyJSONPatch(want, []jsonPatchOperation{{Op: "test", Path: "/copied", Value: json.RawMessage(`"d"`)}}); err == nil {
+		t.Fatal("expected failed test operation to return an error")
+	}
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+// validateArtifactTags checks tags against the JSON Schema subset used by the
+// artifact tag schemas: type, enum, const, required, properties,
+// additionalProperties, items, length/range bounds, pattern and local $ref.
+func validateArtifactTags(tags map[string]any, schema map[string]any) error {
+	if err := validateSchemaValue("$", tags, schema, schema); err != nil {
+		return utils.NewInvalidInputError("artifact tags do not satisfy tag schema", err)
+	}
+	return nil
+}
+
+func validateSchemaValue(path string, value any, schema map[string]any, root map[string]any) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := resolveSchemaRef(ref, root)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		schema = resolved
+	}
+
+	if rawType, ok := schema["type"]; ok && !matchesSchemaType(value, rawType) {
+		return fmt.Errorf("%s: expected type %v", path, rawType)
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		matched := false
+		for _, candidate := range enum {
+			if reflect.DeepEqual(candidate, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: value %v is not one of %v", path, value, enum)
+		}
+	}
+	if constValue, ok := schema["const"]; ok && !reflect.DeepEqual(constValue, value) {
+		return fmt.Errorf("%s: value %v does not equal %v", path, value, constValue)
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		return validateSchemaObject(path, typed, schema, root)
+	case []any:
+		if min, ok := schemaNumber(schema, "minItems"); ok && float64(len(typed)) < min {
+			return fmt.Errorf("%s: expected at least %v items", path, min)
+		}
+		if max, ok := schemaNumber(schema, "maxItems"); ok && float64(len(typed)) > max {
+			return fmt.Errorf("%s: expected at most %v items", path, max)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range typed {
+				if err := validateSchemaValue(fmt.Sprintf("%s[%d]", path, i), item, items, root); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		length := float64(len([]rune(typed)))
+		if min, ok := schemaNumber(schema, "minLength"); ok && length < min {
+			return fmt.Errorf("%s: expected at least %v characters", path, min)
+		}
+		if max, ok := schemaNumber(schema, "maxLength"); ok && length > max {
+			return fmt.Errorf("%s: expected at most %v characters", path, max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("%s: invalid schema pattern %q: %w", path, pattern, err)
+			}
+			if !re.MatchString(typed) {
+				return fmt.Errorf("%s: value %q does not match pattern %q", path, typed, pattern)
+			}
+		}
+	case float64:
+		if min, ok := schemaNumber(schema, "minimum"); ok && typed < min {
+			return fmt.Errorf("%s: value %v is less than %v", path, typed, min)
+		}
+		if max, ok := schemaNumber(schema, "maximum"); ok && typed > max {
+			return fmt.Errorf("%s: value %v is greater than %v", path, typed, max)
+		}
+	}
+	return nil
+}
+
+func validateSchemaObject(path string, value map[string]any, schema map[string]any, root map[string]any) error {
+	if required, ok := schema["required"].([]any); ok {
+		for _, rawKey := range required {
+			key, _ := rawKey.(string)
+			if _, exists := value[key]; !exists {
+				return fmt.Errorf("%s: missing required field %q", path, key)
+			}
+		}
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	for key, fieldValue := range value {
+		fieldPath := path + "." + key
+		if fieldSchema, ok := properties[key].(map[string]any); ok {
+			if err := validateSchemaValue(fieldPath, fieldValue, fieldSchema, root); err != nil {
+				return err
+			}
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return fmt.Errorf("%s: unexpected field", fieldPath)
+			}
+		case map[string]any:
+			if err := validateSchemaValue(fieldPath, fieldValue, additional, root); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func matchesSchemaType(value any, rawType any) bool {
+	switch typed := rawType.(type) {
+	case string:
+		return matchesSingleSchemaType(value, typed)
+	case []any:
+		for _, candidate := range typed {
+			if name, ok := candidate.(string); ok && matchesSingleSchemaType(value, name) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func matchesSingleSchemaType(value any, typeName string) bool {
+	switch typeName {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
+
+func schemaNumber(schema map[string]any, key string) (float64, bool) {
+	value, ok := schema[key].(float64)
+	return value, ok
+}
+
+func resolveSchemaRef(ref string, root map[string]any) (map[string]any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported schema reference: %s", ref)
+	}
+	path, err := parseJSONPointer(strings.TrimPrefix(ref, "#"))
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := jsonPointerGet(root, path)
+	if err != nil {
+		return nil, fmt.Errorf("unresolved schema reference %s: %w", ref, err)
+	}
+	schema, ok := resolved.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("schema reference %s is not an object", ref)
+	}
+	return schema, nil
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+const defaultVersionMetadataDownloadType = "metadata"
+
+// FetchVersionMetadata loads the version metadata of the artifact built from
+// commitHash and parses it as JSON, XML, YAML, TOML, INI or key=value.
+func (s *artifactService) FetchVersionMetadata(commitHash string, lookup *models.ArtifactLookupOptions, options *models.ArtifactVersionMetadataOptions) (*models.ArtifactVersionMetadataInfo, error) {
+	if options == nil {
+		options = &models.ArtifactVersionMetadataOptions{}
+	}
+	var response struct {
+		Code int                                `json:"code"`
+		Msg  string                             `json:"msg"`
+		Data models.ArtifactVersionMetadataInfo `json:"data"`
+	}
+	req := buildCommitHashLookupRequest(commitHash, lookup)
+	if err := s.httpClient.Post("/aiplorer/artifact/version-metadata", req, &response); err != nil {
+		return nil, utils.NewAPIError("failed to get artifact version metadata", err)
+	}
+	if response.Code != 0 {
+		return nil, utils.NewAPIError(response.Msg, nil)
+	}
+	metadata := &response.Data
+	if options.Download {
+		if err := s.downloadVersionMetadata(metadata, commitHash, lookup, options.DownloadType); err != nil {
+			return nil, err
+		}
+	}
+	if metadata.RawContent != nil && *metadata.RawContent != "" {
+		parsed, format, err := parseVersionMetadata(*metadata.RawContent, valueOrEmpty(metadata.MetadataFileName), options.Format)
+		if err != nil {
+			return nil, err
+		}
+		metadata.Parsed = parsed
+		metadata.Format = format
+	}
+	return metadata, nil
+}
+
+// DecodeVersionMetadata decodes version metadata into T. XML is unmarshalled
+// from RawContent with T's xml tags, T standing for the root element, since
+// the generic map loses number, boolean and single-item list types. Other
+// formats are decoded through their JSON form; INI and key=value values are
+// strings.
+func DecodeVersionMetadata[T any](metadata *models.ArtifactVersionMetadataInfo) (*T, error) {
+	if metadata == nil {
+		return nil, utils.NewInvalidInputError("version metadata is nil", nil)
+	}
+	var typed T
+	if metadata.Format == models.ArtifactVersionMetadataXML && valueOrEmpty(metadata.RawContent) != "" {
+		if err := xml.Unmarshal([]byte(*metadata.RawContent), &typed); err != nil {
+			return nil, utils.NewAPIError("failed to decode version metadata", err)
+		}
+	} else if err := remarshalJSON(metadata.Parsed, &typed); err != nil {
+		return nil, utils.NewAPIError("failed to decode version metadata", err)
+	}
+	if err := validateTyped(&typed); err != nil {
+		return nil, utils.NewInvalidInputError("version metadata failed validation", err)
+	}
+	return &typed, nil
+}
+
+// GetVersionMetadataAs loads version metadata and decodes it into T.
+func GetVersionMetadataAs[T any](service ArtifactService, commitHash string, lookup *models.ArtifactLookupOptions, options *models.ArtifactVersionMetadataOptions) (*T, error) {
+	metadata, err := service.FetchVersionMetadata(commitHash, lookup, options)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeVersionMetadata[T](metadata)
+}
+
+// downloadVersionMetadata replaces RawContent with the metadata file
+// downloaded from JFrog.
+func (s *artifactService) downloadVersionMetadata(metadata *models.ArtifactVersionMetadataInfo, commitHash string, lookup *models.ArtifactLookupOptions, downloadType string) error {
+	if downloadType == "" {
+		downloadType = defaultVersionMetadataDownloadType
+	}
+	var artifact *models.ArtifactInfo
+	var err error
+	if metadata.ArtifactID != nil {
+		artifact, err = s.GetArtifactByID(*metadata.ArtifactID)
+	} else {
+		artifact, err = s.GetArtifactByCommitHash(commitHash, lookup)
+	}
+	if err != nil {
+		return err
+	}
+	if artifact.ID == nil {
+		return utils.NewAPIError(fmt.Sprintf("artifact id missing for commit hash: %s", commitHash), nil)
+	}
+	projectName := valueOrEmpty(artifact.ProjectName)
+	if projectName == "" {
+		return utils.NewAPIError(fmt.Sprintf("artifact project_name is empty: %s", commitHash), nil)
+	}
+	token, err := s.GetJfrogToken(projectName)
+	if err != nil {
+		return err
+	}
+	downloadURL, err := s.GetArtifactDownloadURL(*artifact.ID, downloadType)
+	if err != nil {
+		return err
+	}
+
+	tempDir, err := os.MkdirTemp("", "version-metadata-")
+	if err != nil {
+		return utils.NewInternalError("failed to create version metadata directory", err)
+	}
+	defer os.RemoveAll(tempDir)
+	err = s.downloadArtifact(token, downloadURL.FilePath, tempDir)
+	if err != nil && isJfrogAuthError(err) {
+		utils.Warn("JFrog rejected the token for project %s, refreshing: %v", projectName, err)
+		s.jfrogTokens.invalidate(projectName, token)
+		if token, err = s.GetJfrogToken(projectName); err != nil {
+			return err
+		}
+		err = s.downloadArtifact(token, downloadURL.FilePath, tempDir)
+	}
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(filepath.Join(tempDir, path.Base(downloadURL.FilePath)))
+	if err != nil {
+		return utils.NewInternalError("failed to read downloaded version metadata", err)
+	}
+
+	raw := string(content)
+	metadata.RawContent = &raw
+	metadata.ArtifactID = artifact.ID
+	if fileName := firstNonEmpty(downloadURL.FileName, path.Base(downloadURL.FilePath)); valueOrEmpty(metadata.MetadataFileName) == "" {
+		metadata.MetadataFileName = &fileName
+	}
+	if downloadURL.FilePath != "" && valueOrEmpty(metadata.MetadataPath) == "" {
+		metadata.MetadataPath = &downloadURL.FilePath
+	}
+	return nil
+}
+
+// parseVersionMetadata parses rawContent in the given format, the format
+// implied by fileName, or the first format that fits when neither is known.
+func parseVersionMetadata(rawContent, fileName string, format models.ArtifactVersionMetadataFormat) (map[string]any, models.ArtifactVersionMetadataFormat, error) {
+	if format == "" {
+		format = versionMetadataFormatFromName(fileName)
+	}
+	if format != "" {
+		parsed, err := parseVersionMetadataAs(rawContent, format)
+		if err != nil {
+			return nil, format, utils.NewAPIError(fmt.Sprintf("failed to parse version metadata as %s", format), err)
+		}
+		return parsed, format, nil
+	}
+
+	candidates := []models.ArtifactVersionMetadataFormat{
+		models.ArtifactVersionMetadataJSON,
+		models.ArtifactVersionMetadataTOML,
+		models.ArtifactVersionMetadataYAML,
+		models.ArtifactVersionMetadataProperties,
+	}
+	if strings.HasPrefix(strings.TrimSpace(rawContent), "<") {
+		candidates = []models.ArtifactVersionMetadataFormat{models.ArtifactVersionMetadataXML}
+	}
+	var lastErr error
+	for _, candidate := range candidates {
+		parsed, err := parseVersionMetadataAs(rawContent, candidate)
+		if err == nil {
+			return parsed, candidate, nil
+		}
+		lastErr = err
+	}
+	return nil, "", utils.NewAPIError("failed to parse version metadata", lastErr)
+}
+
+func versionMetadataFormatFromName(fileName string) models.ArtifactVersionMetadataFormat {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".json":
+		return models.ArtifactVersionMetadataJSON
+	case ".xml":
+		return models.ArtifactVersionMetadataXML
+	case ".yaml", ".yml":
+		return models.ArtifactVersionMetadataYAML
+	case ".toml":
+		return models.ArtifactVersionMetadataTOML
+	case ".ini", ".cfg":
+		return models.ArtifactVersionMetadataINI
+	case ".properties", ".env":
+		return models.ArtifactVersionMetadataProperties
+	}
+	return ""
+}
+
+func parseVersionMetadataAs(rawContent string, format models.ArtifactVersionMetadataFormat) (map[string]any, error) {
+	switch format {
+	case models.ArtifactVersionMetadataJSON:
+		return models.ParseJSON(rawContent)
+	case models.ArtifactVersionMetadataXML:
+		return parseXMLMetadata(rawContent)
+	case models.ArtifactVersionMetadataYAML:
+		var parsed map[string]any
+		if err := yaml.Unmarshal([]byte(rawContent), &parsed); err != nil {
+			return nil, err
+		}
+		if parsed == nil {
+			return nil, fmt.Errorf("yaml document is not a mapping")
+		}
+		return normalizeMetadataMap(parsed)
+	case models.ArtifactVersionMetadataTOML:
+		var parsed map[string]any
+		if err := toml.Unmarshal([]byte(rawContent), &parsed); err != nil {
+			return nil, err
+		}
+		return normalizeMetadataMap(parsed)
+	case models.ArtifactVersionMetadataINI:
+		return parseKeyValueMetadata(rawContent, true)
+	case models.ArtifactVersionMetadataProperties:
+		return parseKeyValueMetadata(rawContent, false)
+	default:
+		return nil, utils.NewInvalidInputError(fmt.Sprintf("unsupported version metadata format: %s", format), nil)
+	}
+}
+
+// normalizeMetadataMap converts decoder-specific values (integers, times)
+// to the same shapes ParseJSON produces.
+func normalizeMetadataMap(parsed map[string]any) (map[string]any, error) {
+	raw, err := json.Marshal(parsed)
+	if err != nil {
+		return nil, err
+	}
+	return models.ParseJSON(string(raw))
+}
+
+// parseKeyValueMetadata parses key=value lines. With sections, [name]
+// headers start a nested map as in INI files; keys before the first header
+// stay at the top level. "key: value", comments starting with # or ; and
+// a leading "export " are accepted; surrounding quotes are removed.
+func parseKeyValueMetadata(rawContent string, sections bool) (map[string]any, error) {
+	result := map[string]any{}
+	current := result
+	scanner := bufio.NewScanner(strings.NewReader(rawContent))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		if sections && strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			section, ok := result[name].(map[string]any)
+			if !ok {
+				section = map[string]any{}
+				result[name] = section
+			}
+			current = section
+			continue
+		}
+		index := strings.IndexAny(line, "=:")
+		if index <= 0 {
+			return nil, fmt.Errorf("line %d is not a key=value pair", lineNumber)
+		}
+		key := strings.TrimSpace(strings.TrimPrefix(line[:index], "export "))
+		current[key] = unquoteMetadataValue(strings.TrimSpace(line[index+1:]))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func unquoteMetadataValue(value string) string {
+	if len(value) >= 2 {
+		if first, last := value[0], value[len(value)-1]; first == last && (first == '"' || first == '\'') {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
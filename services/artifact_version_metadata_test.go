@@ -0,0 +1,114 @@
+package services
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func TestParseVersionMetadataFormats(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		fileName string
+		raw      string
+		format   models.ArtifactVersionMetadataFormat
+		check    func(map[string]any) bool
+	}{
+		{"yaml", "version.yml", "version: 1.2.3\nbuild:\n  number: 7\n", models.ArtifactVersionMetadataYAML,
+			func(parsed map[string]any) bool { return parsed["build"].(map[string]any)["number"] == float64(7) }},
+		{"toml", "version.toml", "version = \"1.2.3\"\n[build]\nnumber = 7\n", models.ArtifactVersionMetadataTOML,
+			func(parsed map[string]any) bool { return parsed["build"].(map[string]any)["number"] == float64(7) }},
+		{"ini", "version.ini", "; comment\nversion = 1.2.3\n[build]\nnumber = 7\n", models.ArtifactVersionMetadataINI,
+			func(parsed map[string]any) bool { return parsed["build"].(map[string]any)["number"] == "7" }},
+		{"properties", "build.env", "# comment\nexport version=\"1.2.3\"\nbuild.number: 7\n", models.ArtifactVersionMetadataProperties,
+			func(parsed map[string]any) bool { return parsed["build.number"] == "7" }},
+		{"sniffed xml", "VERSION", "<version><number>1.2.3</number></version>", models.ArtifactVersionMetadataXML,
+			func(parsed map[string]any) bool { return parsed["version"].(map[string]any)["number"] == "1.2.3" }},
+		{"sniffed key=value", "VERSION", "version=1.2.3\n", models.ArtifactVersionMetadataProperties,
+			func(parsed map[string]any) bool { return parsed["version"] == "1.2.3" }},
+	} {
+		parsed, format, err := parseVersionMetadata(tc.raw, tc.fileName, "")
+		if err != nil {
+			t.Fatalf("%s: parseVersionMetadata error: %v", tc.name, err)
+		}
+		if format != tc.format || !tc.check(parsed) {
+			t.Fatalf("%s: unexpected result %s %#v", tc.name, format, parsed)
+		}
+	}
+
+	if _, _, err := parseVersionMetadata("not json", "version.json", ""); err == nil {
+		t.Fatal("content not matching the file extension must fail")
+	}
+	if _, format, err := parseVersionMetadata("a: b\n", "version.json", models.ArtifactVersionMetadataYAML); err != nil || format != models.ArtifactVersionMetadataYAML {
+		t.Fatalf("an explicit format must override the file name: %s %v", format, err)
+	}
+}
+
+func TestFetchVersionMetadataDownloadAndDecode(t *testing.T) {
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeBody(t, r)
+		switch r.URL.Path {
+		case "/aiplorer/artifact/version-metadata":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"artifactId":12,"commitHash":"root-hash","rawContent":"{\"version\":\"0.0.0\"}"}}`))
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":12,"projectName":"proj-a"}}`))
+		case "/aiplorer/jfrog/token":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"access_token":"token","url":"https://jfrog.example.com"}}`))
+		case "/aiplorer/artifact/download-url":
+			if payload["downloadType"] != "metadata" {
+				t.Fatalf("unexpected download type: %#v", payload)
+			}
+			_, _ = w.Write([]byte(`{"code":0,"data":{"fileName":"version.yaml","filePath":"repo/path/version.yaml"}}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+	service.downloadArtifact = func(token *models.JfrogTokenInfo, filePath, targetDir string) error {
+		if token.AccessToken != "token" || filePath != "repo/path/version.yaml" {
+			t.Fatalf("unexpected download args: %#v %s", token, filePath)
+		}
+		return os.WriteFile(filepath.Join(targetDir, "version.yaml"), []byte("version: 1.2.3\nbuild: 7\n"), 0o644)
+	}
+
+	type versionInfo struct {
+		Version string `json:"version"`
+		Build   int    `json:"build"`
+	}
+	options := &models.ArtifactVersionMetadataOptions{Download: true}
+	info, err := GetVersionMetadataAs[versionInfo](service, "root-hash", nil, options)
+	if err != nil {
+		t.Fatalf("GetVersionMetadataAs error: %v", err)
+	}
+	if info.Version != "1.2.3" || info.Build != 7 {
+		t.Fatalf("unexpected decoded metadata: %#v", info)
+	}
+
+	metadata, err := service.FetchVersionMetadata("root-hash", nil, options)
+	if err != nil || metadata.Format != models.ArtifactVersionMetadataYAML || valueOrEmpty(metadata.MetadataFileName) != "version.yaml" {
+		t.Fatalf("unexpected downloaded metadata: %#v %v", metadata, err)
+	}
+}
+
+func TestDecodeVersionMetadataFromXML(t *testing.T) {
+	raw := `<bsp release="true"><version>1.2.3</version><build>7</build><board><name>x9</name></board></bsp>`
+	parsed, format, err := parseVersionMetadata(raw, "bsp_version.xml", "")
+	if err != nil {
+		t.Fatalf("parseVersionMetadata error: %v", err)
+	}
+	type bspVersion struct {
+		Release bool     `xml:"release,attr"`
+		Version string   `xml:"version"`
+		Build   int      `xml:"build"`
+		Boards  []string `xml:"board>name"`
+	}
+	info, err := DecodeVersionMetadata[bspVersion](&models.ArtifactVersionMetadataInfo{RawContent: &raw, Parsed: parsed, Format: format})
+	if err != nil {
+		t.Fatalf("DecodeVersionMetadata error: %v", err)
+	}
+	if !info.Release || info.Version != "1.2.3" || info.Build != 7 || len(info.Boards) != 1 || info.Boards[0] != "x9" {
+		t.Fatalf("unexpected decoded metadata: %#v", info)
+	}
+}
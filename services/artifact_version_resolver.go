@@ -0,0 +1,341 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+func (s *artifactService) ResolveArtifactVersion(req *models.ArtifactVersionResolveReq) (*models.ArtifactVersionResolution, error) {
+	if req == nil || strings.TrimSpace(req.Name) == "" {
+		return nil, utils.NewInvalidInputError("artifact name is required for version resolution", nil)
+	}
+	constraint, err := parseSemverConstraint(req.Constraint)
+	if err != nil {
+		return nil, utils.NewInvalidInputError(fmt.Sprintf("invalid semantic version constraint: %s", req.Constraint), err)
+	}
+
+	var lookup models.ArtifactLookupOptions
+	if req.Lookup != nil {
+		lookup = *req.Lookup
+	}
+	lookup.SemanticVersion = ""
+	listReq := buildNameLookupListRequest(req.Name, &lookup)
+
+	type candidate struct {
+		artifact models.ArtifactInfo
+		version  semVersion
+	}
+	var matched []candidate
+	if err := s.forEachArtifact(listReq, func(item models.ArtifactInfo) (bool, error) {
+		if item.Name == nil || *item.Name != req.Name || item.SemanticVersion == nil {
+			return true, nil
+		}
+		version, err := parseSemver(*item.SemanticVersion)
+		if err != nil {
+			utils.Debug("Skipping artifact %s with non-semver version %q", req.Name, *item.SemanticVersion)
+			return true, nil
+		}
+		if version.isPrerelease() && !req.IncludePrerelease {
+			return true, nil
+		}
+		if constraint.matches(version) {
+			matched = append(matched, candidate{artifact: item, version: version})
+		}
+		return true, nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(matched) == 0 {
+		return nil, utils.NewAPIError(fmt.Sprintf("artifact not found by name %s and version constraint %q", req.Name, req.Constraint), nil)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		if cmp := matched[i].version.compare(matched[j].version); cmp != 0 {
+			return cmp > 0
+		}
+		return int64Value(matched[i].artifact.BuildDate) > int64Value(matched[j].artifact.BuildDate)
+	})
+	result := &models.ArtifactVersionResolution{
+		Constraint: req.Constraint,
+		Candidates: make([]models.ArtifactInfo, 0, len(matched)),
+	}
+	for _, item := range matched {
+		result.Candidates = append(result.Candidates, item.artifact)
+	}
+	if matched[0].artifact.ID == nil {
+		return nil, utils.NewAPIError(fmt.Sprintf("artifact id missing for artifact: %s", req.Name), nil)
+	}
+	best, err := s.GetArtifactByID(*matched[0].artifact.ID)
+	if err != nil {
+		return nil, err
+	}
+	result.Best = best
+	return result, nil
+}
+
+func int64Value(value *int64) int64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+// semVersion is a parsed semantic version. Missing minor/patch components are
+// treated as zero so that tags such as v1.4 still take part in resolution.
+type semVersion struct {
+	major, minor, patch int64
+	prerelease          []string
+}
+
+func parseSemver(raw string) (semVersion, error) {
+	text := strings.TrimPrefix(strings.TrimSpace(raw), "v")
+	if index := strings.Index(text, "+"); index >= 0 {
+		text = text[:index]
+	}
+	var version semVersion
+	if index := strings.Index(text, "-"); index >= 0 {
+		version.prerelease = strings.Split(text[index+1:], ".")
+		text = text[:index]
+	}
+	parts := strings.Split(text, ".")
+	if len(parts) == 0 || len(parts) > 3 || parts[0] == "" {
+		return semVersion{}, fmt.Errorf("invalid semantic version: %s", raw)
+	}
+	numbers := make([]int64, 3)
+	for i, part := range parts {
+		number, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || number < 0 {
+			return semVersion{}, fmt.Errorf("invalid semantic version: %s", raw)
+		}
+		numbers[i] = number
+	}
+	version.major, version.minor, version.patch = numbers[0], numbers[1], numbers[2]
+	return version, nil
+}
+
+func (v semVersion) isPrerelease() bool {
+	return len(v.prerelease) > 0
+}
+
+func (v semVersion) String() string {
+	text := fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+	if v.isPrerelease() {
+		text += "-" + strings.Join(v.prerelease, ".")
+	}
+	return text
+}
+
+// compare orders versions by semver precedence.
+func (v semVersion) compare(other semVersion) int {
+	for _, pair := range [][2]int64{{v.major, other.major}, {v.minor, other.minor}, {v.patch, other.patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case !v.isPrerelease() && !other.isPrerelease():
+		return 0
+	case !v.isPrerelease():
+		return 1
+	case !other.isPrerelease():
+		return -1
+	}
+	for i := 0; i < len(v.prerelease) && i < len(other.prerelease); i++ {
+		a, b := v.prerelease[i], other.prerelease[i]
+		if a == b {
+			continue
+		}
+		aNum, aErr := strconv.ParseInt(a, 10, 64)
+		bNum, bErr := strconv.ParseInt(b, 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum < bNum {
+				return -1
+			}
+			return 1
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		case a < b:
+			return -1
+		default:
+			return 1
+		}
+	}
+	switch {
+	case len(v.prerelease) < len(other.prerelease):
+		return -1
+	case len(v.prerelease) > len(other.prerelease):
+		return 1
+	default:
+		return 0
+	}
+}
+
+type semverComparator struct {
+	op      string
+	version semVersion
+}
+
+func (c semverComparator) matches(version semVersion) bool {
+	cmp := version.compare(c.version)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// semverConstraint is a disjunction of comparator sets; every comparator in a
+// set must match.
+type semverConstraint [][]semverComparator
+
+func (c semverConstraint) matches(version semVersion) bool {
+	if len(c) == 0 {
+		return true
+	}
+	for _, set := range c {
+		matched := true
+		for _, comparator := range set {
+			if !comparator.matches(version) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func parseSemverConstraint(raw string) (semverConstraint, error) {
+	text := strings.TrimSpace(raw)
+	if text == "" || text == "*" || strings.EqualFold(text, "latest") {
+		return nil, nil
+	}
+	var constraint semverConstraint
+	for _, alternative := range strings.Split(text, "||") {
+		var set []semverComparator
+		fields := strings.FieldsFunc(alternative, func(r rune) bool { return r == ' ' || r == ',' })
+		for i := 0; i < len(fields); i++ {
+			field := fields[i]
+			// Allow a space between an operator and its version, e.g. ">= 1.2".
+			if strings.Trim(field, "<>=~^") == "" && i+1 < len(fields) {
+				field += fields[i+1]
+				i++
+			}
+			comparators, err := parseSemverComparator(field)
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, comparators...)
+		}
+		if len(set) == 0 {
+			return nil, fmt.Errorf("empty constraint in %q", raw)
+		}
+		constraint = append(constraint, set)
+	}
+	return constraint, nil
+}
+
+func parseSemverComparator(raw string) ([]semverComparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(raw, prefix) {
+			op = prefix
+			break
+		}
+	}
+	text := strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(raw, op)), "v")
+	if text == "*" || strings.EqualFold(text, "x") {
+		return nil, nil
+	}
+
+	// Count the explicit numeric components; x/* wildcards end the version.
+	parts := strings.Split(strings.SplitN(strings.SplitN(text, "-", 2)[0], "+", 2)[0], ".")
+	explicit := 0
+	for _, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		explicit++
+	}
+	if explicit == 0 {
+		return nil, fmt.Errorf("invalid version in constraint: %s", raw)
+	}
+	if explicit < len(parts) {
+		text = strings.Join(parts[:explicit], ".")
+	}
+	version, err := parseSemver(text)
+	if err != nil {
+		return nil, err
+	}
+
+	lower := semverComparator{op: ">=", version: version}
+	switch op {
+	case "^":
+		upper := semVersion{major: version.major + 1}
+		switch {
+		case version.major == 0 && explicit >= 2 && version.minor > 0:
+			upper = semVersion{minor: version.minor + 1}
+		case version.major == 0 && explicit == 3 && version.minor == 0:
+			upper = semVersion{patch: version.patch + 1}
+		case version.major == 0 && explicit == 2:
+			upper = semVersion{minor: version.minor + 1}
+		}
+		return []semverComparator{lower, {op: "<", version: upper}}, nil
+	case "~":
+		upper := semVersion{major: version.major, minor: version.minor + 1}
+		if explicit == 1 {
+			upper = semVersion{major: version.major + 1}
+		}
+		return []semverComparator{lower, {op: "<", version: upper}}, nil
+	case "", "=":
+		if explicit == 3 {
+			return []semverComparator{{op: "=", version: version}}, nil
+		}
+		return []semverComparator{lower, {op: "<", version: bumpSemver(version, explicit)}}, nil
+	case ">":
+		if explicit < 3 {
+			return []semverComparator{{op: ">=", version: bumpSemver(version, explicit)}}, nil
+		}
+		return []semverComparator{{op: ">", version: version}}, nil
+	case "<=":
+		if explicit < 3 {
+			return []semverComparator{{op: "<", version: bumpSemver(version, explicit)}}, nil
+		}
+		return []semverComparator{{op: "<=", version: version}}, nil
+	default:
+		return []semverComparator{{op: op, version: version}}, nil
+	}
+}
+
+// bumpSemver returns the first version after the range covered by a partial
+// version with the given number of explicit components, e.g. 1.4 -> 1.5.0.
+func bumpSemver(version semVersion, explicit int) semVersion {
+	switch explicit {
+	case 1:
+		return semVersion{major: version.major + 1}
+	case 2:
+		return semVersion{major: version.major, minor: version.minor + 1}
+	default:
+		return semVersion{major: version.major, minor: version.minor, patch: version.patch + 1}
+	}
+}
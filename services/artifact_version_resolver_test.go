@@ -0,0 +1,129 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func TestSemverConstraintMatching(t *testing.T) {
+	testCases := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"^1.4", "1.4.0", true},
+		{"^1.4", "1.9.3", true},
+		{"^1.4", "2.0.0", false},
+		{"^1.4", "1.3.9", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"~1.4.2", "1.4.7", true},
+		{"~1.4.2", "1.5.0", false},
+		{"~1.4.2", "1.4.1", false},
+		{">=1.2 <2", "1.2.0", true},
+		{">=1.2 <2", "1.99.0", true},
+		{">=1.2 <2", "2.0.0", false},
+		{">= 1.2, < 2", "1.5.0", true},
+		{"1.4.x", "1.4.12", true},
+		{"1.4.x", "1.5.0", false},
+		{"1.4", "1.4.3", true},
+		{"1.4.3", "1.4.3", true},
+		{"1.4.3", "1.4.4", false},
+		{"<=1.4", "1.4.9", true},
+		{">1.4", "1.4.9", false},
+		{">1.4", "1.5.0", true},
+		{"^1.0 || ^3.0", "3.1.0", true},
+		{"^1.0 || ^3.0", "2.1.0", false},
+		{"latest", "9.9.9", true},
+	}
+	for _, tc := range testCases {
+		constraint, err := parseSemverConstraint(tc.constraint)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.constraint, err)
+		}
+		version, err := parseSemver(tc.version)
+		if err != nil {
+			t.Fatalf("parse version %q: %v", tc.version, err)
+		}
+		if got := constraint.matches(version); got != tc.want {
+			t.Errorf("%q matches %q = %v, want %v", tc.constraint, tc.version, got, tc.want)
+		}
+	}
+}
+
+func TestSemverPrecedence(t *testing.T) {
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "v1.1"}
+	for i := 1; i < len(ordered); i++ {
+		prev, _ := parseSemver(ordered[i-1])
+		next, _ := parseSemver(ordered[i])
+		if prev.compare(next) >= 0 {
+			t.Fatalf("expected %s < %s", ordered[i-1], ordered[i])
+		}
+	}
+}
+
+func TestResolveArtifactVersion(t *testing.T) {
+	listCalls := 0
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/artifact/list":
+			listCalls++
+			payload := decodeBody(t, r)
+			if payload["name"].(string) != "vision" || payload["platform"].(string) != "x9" {
+				t.Fatalf("unexpected list payload: %#v", payload)
+			}
+			if _, ok := payload["semanticVersion"]; ok {
+				t.Fatalf("semantic version filter must not be sent: %#v", payload)
+			}
+			if payload["page"].(float64) == 1 {
+				_, _ = w.Write([]byte(`{"code":0,"data":{"total":5,"data":[` +
+					`{"id":1,"name":"vision","semanticVersion":"1.4.1"},` +
+					`{"id":2,"name":"vision","semanticVersion":"1.5.0-rc.1"}]}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":0,"data":{"total":5,"data":[` +
+				`{"id":3,"name":"vision","semanticVersion":"1.4.3"},` +
+				`{"id":4,"name":"vision","semanticVersion":"2.0.0"},` +
+				`{"id":5,"name":"vision","semanticVersion":"nightly"}]}}`))
+		case "/aiplorer/artifact":
+			payload := decodeBody(t, r)
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":` + mustJSON(payload["id"]) + `,"name":"vision"}}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	platform := "x9"
+	resolution, err := service.ResolveArtifactVersion(&models.ArtifactVersionResolveReq{
+		Name:       "vision",
+		Lookup:     &models.ArtifactLookupOptions{Platform: &platform, SemanticVersion: "1.4.1"},
+		Constraint: "^1.4",
+	})
+	if err != nil {
+		t.Fatalf("ResolveArtifactVersion error: %v", err)
+	}
+	if resolution.Best == nil || *resolution.Best.ID != 3 {
+		t.Fatalf("unexpected best match: %#v", resolution.Best)
+	}
+	if len(resolution.Candidates) != 2 || *resolution.Candidates[1].ID != 1 {
+		t.Fatalf("unexpected candidates: %#v", resolution.Candidates)
+	}
+	if listCalls != 2 {
+		t.Fatalf("expected two list pages, got %d", listCalls)
+	}
+
+	resolution, err = service.ResolveArtifactVersion(&models.ArtifactVersionResolveReq{
+		Name:              "vision",
+		Lookup:            &models.ArtifactLookupOptions{Platform: &platform},
+		Constraint:        "~1.5.0-rc.0",
+		IncludePrerelease: true,
+	})
+	if err != nil {
+		t.Fatalf("ResolveArtifactVersion prerelease error: %v", err)
+	}
+	if *resolution.Best.ID != 2 {
+		t.Fatalf("unexpected prerelease best match: %#v", resolution.Best)
+	}
+}
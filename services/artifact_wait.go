@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+const (
+	defaultWaitPollInterval = 5 * time.Second
+	defaultWaitMaxInterval  = time.Minute
+)
+
+// WaitForArtifactByCommitHash blocks until the artifact for commitHash exists
+// with a FullPath and FileHash, polling with exponential backoff. It gives up
+// with a not-found error wrapping the context error once ctx is done or
+// options.Timeout elapses.
+func (s *artifactService) WaitForArtifactByCommitHash(ctx context.Context, commitHash string, lookup *models.ArtifactLookupOptions, options *models.ArtifactWaitOptions) (*models.ArtifactInfo, error) {
+	artifacts, err := s.WaitForArtifactsByCommitHashes(ctx, []string{commitHash}, lookup, options)
+	if err != nil {
+		return nil, err
+	}
+	return artifacts[commitHash], nil
+}
+
+// WaitForArtifactsByCommitHashes waits until every commit hash has an
+// available artifact. On timeout it returns the artifacts found so far
+// together with an error naming the missing commit hashes.
+func (s *artifactService) WaitForArtifactsByCommitHashes(ctx context.Context, commitHashes []string, lookup *models.ArtifactLookupOptions, options *models.ArtifactWaitOptions) (map[string]*models.ArtifactInfo, error) {
+	if len(commitHashes) == 0 {
+		return nil, utils.NewInvalidInputError("at least one commit hash is required", nil)
+	}
+	pending := map[string]bool{}
+	for _, commitHash := range commitHashes {
+		if strings.TrimSpace(commitHash) == "" {
+			return nil, utils.NewInvalidInputError("commit hash is required", nil)
+		}
+		pending[commitHash] = true
+	}
+	if options == nil {
+		options = &models.ArtifactWaitOptions{}
+	}
+	interval := options.PollInterval
+	if interval <= 0 {
+		interval = defaultWaitPollInterval
+	}
+	maxInterval := options.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultWaitMaxInterval
+	}
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	found := make(map[string]*models.ArtifactInfo, len(pending))
+	for {
+		for commitHash := range pending {
+			artifact, err := s.GetArtifactByCommitHash(commitHash, lookup)
+			if err != nil {
+				if isArtifactNotFoundByCommitHash(err) {
+					continue
+				}
+				return found, err
+			}
+			if artifactAvailable(artifact) {
+				found[commitHash] = artifact
+				delete(pending, commitHash)
+			}
+		}
+		if len(pending) == 0 {
+			return found, nil
+		}
+
+		utils.Debug("Waiting %s for %d artifact(s) to become available", interval, len(pending))
+		select {
+		case <-ctx.Done():
+			missing := make([]string, 0, len(pending))
+			for commitHash := range pending {
+				missing = append(missing, commitHash)
+			}
+			sort.Strings(missing)
+			return found, utils.NewNotFoundError(fmt.Sprintf("artifacts not available for commit hashes: %s", strings.Join(missing, ", ")), ctx.Err())
+		case <-time.After(interval):
+		}
+		interval *= 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+// artifactAvailable reports whether the artifact has been uploaded.
+func artifactAvailable(artifact *models.ArtifactInfo) bool {
+	return artifact.FullPath != nil && *artifact.FullPath != "" &&
+		artifact.FileHash != nil && *artifact.FileHash != ""
+}
+
+func isArtifactNotFoundByCommitHash(err error) bool {
+	return strings.Contains(err.Error(), "artifact not found by commit hash")
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+func TestWaitForArtifactsByCommitHashes(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/aiplorer/artifact/by-commit-hash" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		hash := decodeBody(t, r)["commitHash"].(string)
+		mu.Lock()
+		calls[hash]++
+		attempt := calls[hash]
+		mu.Unlock()
+		switch {
+		case hash == "upstream-a" && attempt == 1:
+			_, _ = w.Write([]byte(`{"code":500,"msg":"artifact not found by commit hash: upstream-a"}`))
+		case hash == "upstream-a" && attempt == 2:
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":1,"commitHash":"upstream-a"}}`))
+		case hash == "never":
+			_, _ = w.Write([]byte(`{"code":500,"msg":"artifact not found by commit hash: never"}`))
+		default:
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":2,"commitHash":"` + hash + `","fullPath":"repo/a.tar.gz","fileHash":"` + helloMD5 + `"}}`))
+		}
+	})
+	options := &models.ArtifactWaitOptions{PollInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond}
+
+	artifacts, err := service.WaitForArtifactsByCommitHashes(context.Background(), []string{"upstream-a", "upstream-b"}, nil, options)
+	if err != nil {
+		t.Fatalf("WaitForArtifactsByCommitHashes error: %v", err)
+	}
+	if len(artifacts) != 2 || calls["upstream-a"] != 3 || calls["upstream-b"] != 1 {
+		t.Fatalf("unexpected wait result: %#v calls=%v", artifacts, calls)
+	}
+
+	options.Timeout = 20 * time.Millisecond
+	artifact, err := service.WaitForArtifactByCommitHash(context.Background(), "never", nil, options)
+	var sdkErr *utils.SDKError
+	if artifact != nil || !errors.As(err, &sdkErr) || sdkErr.Code != utils.ErrCodeNotFound || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected timeout error, got %#v %v", artifact, err)
+	}
+}
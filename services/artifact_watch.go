@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+const (
+	defaultWatchPollInterval = 30 * time.Second
+	defaultWatchMaxBackoff   = 5 * time.Minute
+)
+
+// WatchArtifacts polls for artifacts matching the options and calls handler
+// for every new or updated one, oldest change first. The checkpoint advances
+// only after the handler succeeds, so delivery is at-least-once across
+// restarts. It returns when ctx is done or the handler fails.
+func (s *artifactService) WatchArtifacts(ctx context.Context, options *models.ArtifactWatchOptions, handler func(event models.ArtifactWatchEvent) error) error {
+	if handler == nil {
+		return utils.NewInvalidInputError("artifact watch handler is required", nil)
+	}
+	if options == nil {
+		options = &models.ArtifactWatchOptions{}
+	}
+	pollInterval := options.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultWatchPollInterval
+	}
+	maxBackoff := options.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultWatchMaxBackoff
+	}
+	if maxBackoff < pollInterval {
+		maxBackoff = pollInterval
+	}
+
+	checkpoint := options.Checkpoint
+	if checkpoint == nil && options.CheckpointStore != nil {
+		stored, err := options.CheckpointStore.Load()
+		if err != nil {
+			return err
+		}
+		checkpoint = stored
+	}
+	baseline := checkpoint == nil && !options.FromBeginning
+	if checkpoint == nil {
+		checkpoint = &models.ArtifactWatchCheckpoint{}
+	}
+
+	delay := time.Duration(0)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		changes, err := s.pollArtifactChanges(options.Filter, checkpoint)
+		if err != nil {
+			if delay < pollInterval {
+				delay = pollInterval
+			}
+			delay *= 2
+			if delay > maxBackoff {
+				delay = maxBackoff
+			}
+			utils.Warn("Artifact watch poll failed, retrying in %s: %v", delay, err)
+			continue
+		}
+		delay = pollInterval
+
+		// The checkpoint is saved once per poll, and on the way out so that
+		// events already handled are not delivered again after a restart.
+		dirty := false
+		save := func() error {
+			if !dirty || options.CheckpointStore == nil {
+				return nil
+			}
+			dirty = false
+			return options.CheckpointStore.Save(*checkpoint)
+		}
+		stop := func(err error) error {
+			if saveErr := save(); saveErr != nil {
+				utils.Warn("Failed to save artifact watch checkpoint: %v", saveErr)
+			}
+			return err
+		}
+		for _, item := range changes {
+			if ctx.Err() != nil {
+				return stop(ctx.Err())
+			}
+			eventType := models.ArtifactWatchUpdated
+			if created := int64Value(item.CreatedAt); created > checkpoint.Cursor || created == artifactChangeTime(item) {
+				eventType = models.ArtifactWatchCreated
+			}
+			next := advanceWatchCheckpoint(*checkpoint, item)
+			if !baseline && matchWatchTags(item, options.TagsPredicate) {
+				if err := handler(models.ArtifactWatchEvent{Type: eventType, Artifact: item, Checkpoint: next}); err != nil {
+					return stop(err)
+				}
+			}
+			*checkpoint = next
+			dirty = true
+		}
+		if err := save(); err != nil {
+			return err
+		}
+		baseline = false
+	}
+}
+
+// WatchArtifactsChannel runs WatchArtifacts in the background and delivers
+// events on the returned channel. The error channel receives the terminal
+// error, and both channels are closed when the watcher stops.
+func (s *artifactService) WatchArtifactsChannel(ctx context.Context, options *models.ArtifactWatchOptions) (<-chan models.ArtifactWatchEvent, <-chan error) {
+	events := make(chan models.ArtifactWatchEvent)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(events)
+		errs <- s.WatchArtifacts(ctx, options, func(event models.ArtifactWatchEvent) error {
+			select {
+			case events <- event:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return events, errs
+}
+
+// pollArtifactChanges returns artifacts changed after the checkpoint, oldest
+// first. The list API has no change-time filter, so every artifact matching
+// the filter is listed and compared with the checkpoint cursor.
+func (s *artifactService) pollArtifactChanges(filter *models.ArtifactListReq, checkpoint *models.ArtifactWatchCheckpoint) ([]models.ArtifactInfo, error) {
+	seen := make(map[uint64]bool, len(checkpoint.SeenIDs))
+	for _, id := range checkpoint.SeenIDs {
+		seen[id] = true
+	}
+	var changes []models.ArtifactInfo
+	err := s.forEachArtifact(filter, func(item models.ArtifactInfo) (bool, error) {
+		if item.ID == nil {
+			return true, nil
+		}
+		changed := artifactChangeTime(item)
+		if changed > checkpoint.Cursor || (changed == checkpoint.Cursor && !seen[*item.ID]) {
+			changes = append(changes, item)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if left, right := artifactChangeTime(changes[i]), artifactChangeTime(changes[j]); left != right {
+			return left < right
+		}
+		return *changes[i].ID < *changes[j].ID
+	})
+	return changes, nil
+}
+
+func advanceWatchCheckpoint(checkpoint models.ArtifactWatchCheckpoint, item models.ArtifactInfo) models.ArtifactWatchCheckpoint {
+	changed := artifactChangeTime(item)
+	if changed > checkpoint.Cursor {
+		return models.ArtifactWatchCheckpoint{Cursor: changed, SeenIDs: []uint64{*item.ID}}
+	}
+	seen := append(append([]uint64(nil), checkpoint.SeenIDs...), *item.ID)
+	return models.ArtifactWatchCheckpoint{Cursor: checkpoint.Cursor, SeenIDs: seen}
+}
+
+// artifactChangeTime is the newest of UpdatedAt and CreatedAt.
+func artifactChangeTime(item models.ArtifactInfo) int64 {
+	updated, created := int64Value(item.UpdatedAt), int64Value(item.CreatedAt)
+	if updated > created {
+		return updated
+	}
+	return created
+}
+
+func matchWatchTags(item models.ArtifactInfo, predicate func(tags map[string]any) bool) bool {
+	if predicate == nil {
+		return true
+	}
+	tags := map[string]any{}
+	if item.Tags != nil && strings.TrimSpace(*item.Tags) != "" {
+		parsed, err := models.ParseJSON(*item.Tags)
+		if err != nil {
+			return false
+		}
+		tags = parsed
+	}
+	return predicate(tags)
+}
+
+// FileArtifactCheckpointStore keeps a watcher checkpoint in a JSON file.
+type FileArtifactCheckpointStore struct {
+	Path string
+}
+
+// NewFileArtifactCheckpointStore creates a checkpoint store backed by path.
+func NewFileArtifactCheckpointStore(path string) *FileArtifactCheckpointStore {
+	return &FileArtifactCheckpointStore{Path: path}
+}
+
+// Load reads the checkpoint, returning nil when none has been saved yet.
+func (f *FileArtifactCheckpointStore) Load() (*models.ArtifactWatchCheckpoint, error) {
+	raw, err := os.ReadFile(f.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, utils.NewInternalError("failed to read artifact watch checkpoint", err)
+	}
+	var checkpoint models.ArtifactWatchCheckpoint
+	if err := json.Unmarshal(raw, &checkpoint); err != nil {
+		return nil, utils.NewInternalError("failed to decode artifact watch checkpoint", err)
+	}
+	return &checkpoint, nil
+}
+
+// Save writes the checkpoint atomically.
+func (f *FileArtifactCheckpointStore) Save(checkpoint models.ArtifactWatchCheckpoint) error {
+	raw, err := json.Marshal(checkpoint)
+	if err != nil {
+		return utils.NewInternalError("failed to encode artifact watch checkpoint", err)
+	}
+	temp := f.Path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o755); err != nil {
+		return utils.NewInternalError("failed to create artifact watch checkpoint directory", err)
+	}
+	if err := os.WriteFile(temp, raw, 0o644); err != nil {
+		return utils.NewInternalError("failed to write artifact watch checkpoint", err)
+	}
+	if err := os.Rename(temp, f.Path); err != nil {
+		return utils.NewInternalError("failed to write artifact watch checkpoint", err)
+	}
+	return nil
+}
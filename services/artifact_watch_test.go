@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hujia-team/intranet-sdk/models"
+)
+
+func TestWatchArtifactsDeliversChangesAndResumes(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+	pages := [][]map[string]any{
+		{{"id": 1, "name": "vision", "createdAt": 100, "updatedAt": 100}},
+		{{"id": 1, "name": "vision", "createdAt": 100, "updatedAt": 100}, {"id": 2, "name": "vision", "createdAt": 200}},
+		{
+			{"id": 1, "name": "vision", "createdAt": 100, "updatedAt": 300},
+			{"id": 2, "name": "vision", "createdAt": 200},
+			{"id": 3, "name": "vision", "createdAt": 300, "tags": `{"decision":"fail"}`},
+		},
+	}
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/aiplorer/artifact/list" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		payload := decodeBody(t, r)
+		if payload["name"].(string) != "vision" {
+			t.Fatalf("unexpected list payload: %#v", payload)
+		}
+		mu.Lock()
+		page := pages[len(pages)-1]
+		if polls < len(pages) {
+			page = pages[polls]
+		}
+		polls++
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"code":0,"data":` + mustJSON(map[string]any{"total": len(page), "data": page}) + `}`))
+	})
+
+	name := "vision"
+	store := NewFileArtifactCheckpointStore(filepath.Join(t.TempDir(), "watch.json"))
+	options := &models.ArtifactWatchOptions{
+		Filter:          &models.ArtifactListReq{Name: &name},
+		PollInterval:    5 * time.Millisecond,
+		CheckpointStore: store,
+		TagsPredicate: func(tags map[string]any) bool {
+			return tags["decision"] != "fail"
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, errs := service.WatchArtifactsChannel(ctx, options)
+	var received []models.ArtifactWatchEvent
+	for event := range events {
+		received = append(received, event)
+		if len(received) == 2 {
+			cancel()
+		}
+	}
+	<-errs
+	if len(received) != 2 {
+		t.Fatalf("expected two events, got %#v", received)
+	}
+	if received[0].Type != models.ArtifactWatchCreated || *received[0].Artifact.ID != 2 {
+		t.Fatalf("unexpected first event: %#v", received[0])
+	}
+	if received[1].Type != models.ArtifactWatchUpdated || *received[1].Artifact.ID != 1 {
+		t.Fatalf("unexpected second event: %#v", received[1])
+	}
+
+	// Let the filtered artifact advance the checkpoint before restarting.
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	replayed := 0
+	err := service.WatchArtifacts(ctx, options, func(event models.ArtifactWatchEvent) error {
+		replayed++
+		return nil
+	})
+	if err != context.DeadlineExceeded || replayed != 0 {
+		t.Fatalf("restart must not replay events: err=%v replayed=%d", err, replayed)
+	}
+	checkpoint, err := store.Load()
+	if err != nil || checkpoint.Cursor != 300 || len(checkpoint.SeenIDs) != 2 {
+		t.Fatalf("unexpected checkpoint: %#v %v", checkpoint, err)
+	}
+}
+
+type countingCheckpointStore struct {
+	mu    sync.Mutex
+	saved []models.ArtifactWatchCheckpoint
+}
+
+func (c *countingCheckpointStore) Load() (*models.ArtifactWatchCheckpoint, error) {
+	return nil, nil
+}
+
+func (c *countingCheckpointStore) Save(checkpoint models.ArtifactWatchCheckpoint) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.saved = append(c.saved, checkpoint)
+	return nil
+}
+
+func TestWatchArtifactsSavesOncePerPoll(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+	artifacts := []map[string]any{
+		{"id": 1, "createdAt": 100},
+		{"id": 2, "createdAt": 200},
+		{"id": 3, "createdAt": 300},
+	}
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		if payload := decodeBody(t, r); payload["updatedSince"] != nil {
+			t.Fatalf("the list API has no updatedSince filter: %#v", payload)
+		}
+		mu.Lock()
+		polls++
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"code":0,"data":` + mustJSON(map[string]any{"total": len(artifacts), "data": artifacts}) + `}`))
+	})
+
+	store := &countingCheckpointStore{}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := service.WatchArtifacts(ctx, &models.ArtifactWatchOptions{
+		PollInterval:    5 * time.Millisecond,
+		CheckpointStore: store,
+	}, func(event models.ArtifactWatchEvent) error {
+		t.Fatalf("baseline must not deliver events: %#v", event)
+		return nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("unexpected watch error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if polls < 2 {
+		t.Fatalf("expected several polls, got %d", polls)
+	}
+	if len(store.saved) != 1 || store.saved[0].Cursor != 300 || len(store.saved[0].SeenIDs) != 1 {
+		t.Fatalf("expected one checkpoint save for the baseline poll, got %#v", store.saved)
+	}
+}
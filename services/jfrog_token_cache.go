@@ -0,0 +1,106 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"golang.org/x/sync/singleflight"
+)
+
+const maxJfrogTokenRefreshWindow = time.Minute
+
+// jfrogTokenCache keeps one JFrog token per project. Tokens are refreshed
+// shortly before they expire and concurrent misses for the same project
+// share a single fetch.
+type jfrogTokenCache struct {
+	mu      sync.Mutex
+	entries map[string]jfrogTokenEntry
+	group   singleflight.Group
+	now     func() time.Time
+}
+
+type jfrogTokenEntry struct {
+	token     models.JfrogTokenInfo
+	refreshAt time.Time
+}
+
+func newJfrogTokenCache() *jfrogTokenCache {
+	return &jfrogTokenCache{
+		entries: map[string]jfrogTokenEntry{},
+		now:     time.Now,
+	}
+}
+
+// get returns a cached token for project or calls fetch to obtain one.
+// Callers receive their own copy of the token.
+func (c *jfrogTokenCache) get(project string, fetch func() (*models.JfrogTokenInfo, error)) (*models.JfrogTokenInfo, error) {
+	if token, ok := c.lookup(project); ok {
+		return token, nil
+	}
+	value, err, _ := c.group.Do(project, func() (any, error) {
+		if token, ok := c.lookup(project); ok {
+			return token, nil
+		}
+		token, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		c.store(project, *token)
+		return token, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	token := *value.(*models.JfrogTokenInfo)
+	return &token, nil
+}
+
+func (c *jfrogTokenCache) lookup(project string) (*models.JfrogTokenInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[project]
+	if !ok {
+		return nil, false
+	}
+	if !entry.refreshAt.IsZero() && !c.now().Before(entry.refreshAt) {
+		delete(c.entries, project)
+		return nil, false
+	}
+	token := entry.token
+	return &token, true
+}
+
+// store caches token until shortly before it expires: a fifth of its
+// lifetime early, but never more than a minute. Tokens without an expiry
+// stay cached until invalidated.
+func (c *jfrogTokenCache) store(project string, token models.JfrogTokenInfo) {
+	entry := jfrogTokenEntry{token: token}
+	if token.ExpiresIn > 0 {
+		lifetime := time.Duration(token.ExpiresIn) * time.Second
+		window := lifetime / 5
+		if window > maxJfrogTokenRefreshWindow {
+			window = maxJfrogTokenRefreshWindow
+		}
+		entry.refreshAt = c.now().Add(lifetime - window)
+	}
+	c.mu.Lock()
+	c.entries[project] = entry
+	c.mu.Unlock()
+}
+
+// invalidate drops the cached token for project. When rejected is set, the
+// entry is only dropped if it still holds that token, so a token refreshed
+// by another goroutine survives a late failure report.
+func (c *jfrogTokenCache) invalidate(project string, rejected *models.JfrogTokenInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[project]
+	if !ok {
+		return
+	}
+	if rejected != nil && entry.token.AccessToken != rejected.AccessToken {
+		return
+	}
+	delete(c.entries, project)
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hujia-team/intranet-sdk/models"
+	"github.com/hujia-team/intranet-sdk/utils"
+)
+
+func TestGetJfrogTokenCachesPerProject(t *testing.T) {
+	var requests atomic.Int32
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/aiplorer/jfrog/token" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		n := requests.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		token := "token-" + decodeBody(t, r)["projectName"].(string) + "-" + string(rune('0'+n))
+		_, _ = w.Write([]byte(`{"code":0,"data":{"access_token":"` + token + `","expires_in":100,"url":"https://jfrog.example.com"}}`))
+	})
+	now := time.Unix(1000, 0)
+	service.jfrogTokens.now = func() time.Time { return now }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.GetJfrogToken("demo"); err != nil {
+				t.Errorf("GetJfrogToken error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if requests.Load() != 1 {
+		t.Fatalf("concurrent requests must share one fetch, got %d", requests.Load())
+	}
+
+	// expires_in=100 refreshes 20s early.
+	now = now.Add(79 * time.Second)
+	if token, _ := service.GetJfrogToken("demo"); token.AccessToken != "token-demo-1" || requests.Load() != 1 {
+		t.Fatalf("token must be reused before the refresh window: %#v", token)
+	}
+	now = now.Add(2 * time.Second)
+	if token, _ := service.GetJfrogToken("demo"); token.AccessToken != "token-demo-2" {
+		t.Fatalf("token must be refreshed near expiry: %#v", token)
+	}
+	if _, err := service.GetJfrogToken("other"); err != nil || requests.Load() != 3 {
+		t.Fatalf("projects must be cached separately: %v requests=%d", err, requests.Load())
+	}
+}
+
+func TestDownloadRefreshesRejectedJfrogToken(t *testing.T) {
+	tokenRequests := 0
+	service := newArtifactTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aiplorer/artifact":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"id":12,"projectName":"proj-a","fullPath":"repo/a.zip"}}`))
+		case "/aiplorer/jfrog/token":
+			tokenRequests++
+			_, _ = w.Write([]byte(`{"code":0,"data":{"access_token":"token-` + string(rune('0'+tokenRequests)) + `","expires_in":3600}}`))
+		case "/aiplorer/artifact/download-url":
+			_, _ = w.Write([]byte(`{"code":0,"data":{"fileName":"a.zip","filePath":"repo/a.zip"}}`))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	})
+	var used []string
+	service.downloadArtifact = func(token *models.JfrogTokenInfo, filePath, targetDir string) error {
+		used = append(used, token.AccessToken)
+		if token.AccessToken == "token-1" {
+			return utils.NewUnauthorizedError("jfrog rejected the access token", nil)
+		}
+		return nil
+	}
+
+	plan, err := service.DownloadByArtifactID(12, t.TempDir()+"/")
+	if err != nil {
+		t.Fatalf("DownloadByArtifactID error: %v", err)
+	}
+	if tokenRequests != 2 || len(used) != 2 || plan.Token.AccessToken != "token-2" {
+		t.Fatalf("expected one refresh after 401: requests=%d used=%v", tokenRequests, used)
+	}
+	if _, err := service.DownloadByArtifactID(12, t.TempDir()+"/"); err != nil || tokenRequests != 2 {
+		t.Fatalf("refreshed token must be cached: %v requests=%d", err, tokenRequests)
+	}
+}
@@ -18,6 +18,10 @@ const (
 	ErrCodeAPIError
 	ErrCodeNetworkError
 	ErrCodeInternalError
+	ErrCodeConflict
+	ErrCodeSignatureInvalid
+	ErrCodeChecksumMismatch
+	ErrCodeUnverified
 )
 
 // String returns the string representation of the error code.
@@ -39,6 +43,14 @@ func (c ErrorCode) String() string {
 		return "network error"
 	case ErrCodeInternalError:
 		return "internal error"
+	case ErrCodeConflict:
+		return "conflict"
+	case ErrCodeSignatureInvalid:
+		return "signature invalid"
+	case ErrCodeChecksumMismatch:
+		return "checksum mismatch"
+	case ErrCodeUnverified:
+		return "unverified"
 	default:
 		return "unknown error code"
 	}
@@ -108,6 +120,27 @@ func NewInternalError(message string, err error) *SDKError {
 	return NewSDKError(ErrCodeInternalError, message, err)
 }
 
+// NewConflictError creates a new conflict error.
+func NewConflictError(message string, err error) *SDKError {
+	return NewSDKError(ErrCodeConflict, message, err)
+}
+
+// NewSignatureError creates a new signature verification error.
+func NewSignatureError(message string, err error) *SDKError {
+	return NewSDKError(ErrCodeSignatureInvalid, message, err)
+}
+
+// NewChecksumError creates a new content checksum mismatch error.
+func NewChecksumError(message string, err error) *SDKError {
+	return NewSDKError(ErrCodeChecksumMismatch, message, err)
+}
+
+// NewUnverifiedError creates a new error for content that could not be
+// verified, such as a stream closed before its end.
+func NewUnverifiedError(message string, err error) *SDKError {
+	return NewSDKError(ErrCodeUnverified, message, err)
+}
+
 // NewLoginError creates a new login error.
 func NewLoginError(message string, err error) *SDKError {
 	return NewSDKError(ErrCodeUnauthorized, "登录失败: "+message, err)